@@ -0,0 +1,290 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"project-group-81/config"
+	"project-group-81/elevator"
+	"project-group-81/journal"
+	"project-group-81/logging"
+	"project-group-81/metrics"
+	"project-group-81/network"
+	"project-group-81/types"
+	"strconv"
+	"time"
+)
+
+var log = logging.For("api")
+
+// Time to wait for the elevator or network node to answer. The network node does not answer while it
+// is searching for a master.
+const REQUEST_TIMEOUT = time.Second * 2
+
+type server struct {
+	stateRequestChan  chan<- chan elevator.Elevator
+	statusRequestChan chan<- chan network.Status
+	remoteButtonChan  chan<- types.Order
+	serviceChan       chan<- bool
+	reassignChan      chan<- bool
+	obstructionChan   chan<- bool
+	stopChan          chan<- bool
+}
+
+// Serves the HTTP status and control API on the address until it fails.
+//
+//	GET  /elevator              State of the local elevator
+//	GET  /network               The node's view of the network: role, processes and assigned orders
+//	GET  /explanations          The latest assignment decisions made while this node was master
+//	POST /orders                Presses a button, given as the form values call (HallUp, HallDown or Car) and floor. With
+//	                            DESTINATION_DISPATCH, hall calls take call Destination and the form value destination instead
+//	POST /service/out           Takes the elevator out of hall service
+//	POST /service/in            Puts the elevator back in hall service
+//	POST /reassign              Makes the master assign every order again
+//	POST /simulate/obstruction  Sets the simulated obstruction switch, given as the form value active (true or false)
+//	POST /simulate/stop         Sets the simulated stop button, given as the form value active
+//	POST /kill                  Ends the node at once, as if it crashed. Forbidden unless API_KILL is set
+//	GET  /journal               Order events recorded by this node, after the RFC 3339 time given as the form value after
+//	GET  /metrics               Metrics of this node in the Prometheus text format
+//	GET  /                      Live dashboard of the building
+//	GET  /building              The building as drawn by the dashboard
+//	GET  /events                The building as Server-Sent Events, sent again on every change
+//
+// Observer nodes have no elevator and pass nil channels for it, which leaves out the endpoints that need them.
+// They pass the channel of the hall calls they forward to the master as remoteButtonChan, and refuse cab calls.
+func Serve(
+	address string,
+	stateRequestChan chan<- chan elevator.Elevator,
+	statusRequestChan chan<- chan network.Status,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool,
+	reassignChan chan<- bool,
+	obstructionChan chan<- bool,
+	stopChan chan<- bool) error {
+
+	s := server{stateRequestChan, statusRequestChan, remoteButtonChan, serviceChan, reassignChan, obstructionChan, stopChan}
+	mux := http.NewServeMux()
+	if stateRequestChan != nil {
+		mux.HandleFunc("/elevator", get(s.elevator))
+		mux.HandleFunc("/service/out", post(s.service(false)))
+		mux.HandleFunc("/service/in", post(s.service(true)))
+		mux.HandleFunc("/reassign", post(s.reassign))
+		mux.HandleFunc("/simulate/obstruction", post(s.simulate(s.obstructionChan)))
+		mux.HandleFunc("/simulate/stop", post(s.simulate(s.stopChan)))
+	}
+	mux.HandleFunc("/network", get(s.network))
+	mux.HandleFunc("/explanations", get(s.explanations))
+	if remoteButtonChan != nil {
+		mux.HandleFunc("/orders", post(s.order))
+	}
+	mux.HandleFunc("/kill", post(s.kill))
+	mux.HandleFunc("/journal", get(s.journal))
+	mux.HandleFunc("/metrics", get(s.metrics))
+	mux.HandleFunc("/", get(s.dashboard))
+	mux.HandleFunc("/building", get(s.buildingJSON))
+	mux.HandleFunc("/events", get(s.events))
+	log.Info("Serving status API", "address", address)
+	return http.ListenAndServe(address, mux)
+}
+
+func get(handler http.HandlerFunc) http.HandlerFunc {
+	return only(http.MethodGet, handler)
+}
+
+func post(handler http.HandlerFunc) http.HandlerFunc {
+	return only(http.MethodPost, handler)
+}
+
+func only(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	blob, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(blob, '\n'))
+}
+
+func (s server) elevator(w http.ResponseWriter, r *http.Request) {
+	reply := make(chan elevator.Elevator, 1)
+	select {
+	case s.stateRequestChan <- reply:
+		writeJSON(w, <-reply)
+	case <-time.After(REQUEST_TIMEOUT):
+		http.Error(w, "elevator is not responding", http.StatusServiceUnavailable)
+	}
+}
+
+func (s server) network(w http.ResponseWriter, r *http.Request) {
+	reply := make(chan network.Status, 1)
+	select {
+	case s.statusRequestChan <- reply:
+		writeJSON(w, <-reply)
+	case <-time.After(REQUEST_TIMEOUT):
+		http.Error(w, "node is searching for a master", http.StatusServiceUnavailable)
+	}
+}
+
+func (s server) explanations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, network.Explanations())
+}
+
+func (s server) metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metrics.WriteText(w)
+}
+
+func (s server) order(w http.ResponseWriter, r *http.Request) {
+	var order types.Order
+	var err error
+	if r.FormValue("call") == types.Destination.String() {
+		order, err = ParseDestinationOrder(r.FormValue("floor"), r.FormValue("destination"))
+	} else {
+		order, err = ParseOrder(r.FormValue("call"), r.FormValue("floor"))
+	}
+	if err == nil && order.C == types.Car && s.stateRequestChan == nil {
+		err = fmt.Errorf("observers have no car to take cab calls")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	select {
+	case s.remoteButtonChan <- order:
+		w.WriteHeader(http.StatusAccepted)
+	case <-time.After(REQUEST_TIMEOUT):
+		if s.stateRequestChan == nil {
+			http.Error(w, "node is searching for a master", http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, "elevator is not responding", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func (s server) service(inService bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.serviceChan <- inService:
+			w.WriteHeader(http.StatusAccepted)
+		case <-time.After(REQUEST_TIMEOUT):
+			http.Error(w, "elevator is not responding", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func (s server) reassign(w http.ResponseWriter, r *http.Request) {
+	select {
+	case s.reassignChan <- true:
+		w.WriteHeader(http.StatusAccepted)
+	case <-time.After(REQUEST_TIMEOUT):
+		http.Error(w, "node is searching for a master", http.StatusServiceUnavailable)
+	}
+}
+
+func (s server) simulate(switchChan chan<- bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		active, err := strconv.ParseBool(r.FormValue("active"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("active must be true or false, got %q", r.FormValue("active")), http.StatusBadRequest)
+			return
+		}
+		select {
+		case switchChan <- active:
+			w.WriteHeader(http.StatusAccepted)
+		case <-time.After(REQUEST_TIMEOUT):
+			http.Error(w, "elevator is not responding", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func (s server) kill(w http.ResponseWriter, r *http.Request) {
+	if !config.API_KILL {
+		http.Error(w, "kill is disabled, set API_KILL in the configuration of the node", http.StatusForbidden)
+		return
+	}
+	log.Warn("Killed through the status API", "client", r.RemoteAddr)
+	w.WriteHeader(http.StatusAccepted)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	os.Exit(1)
+}
+
+func (s server) journal(w http.ResponseWriter, r *http.Request) {
+	var after time.Time
+	if r.FormValue("after") != "" {
+		var err error
+		if after, err = time.Parse(time.RFC3339Nano, r.FormValue("after")); err != nil {
+			http.Error(w, fmt.Sprintf("after must be an RFC 3339 time, got %q", r.FormValue("after")), http.StatusBadRequest)
+			return
+		}
+	}
+	writeJSON(w, journal.Recent(after))
+}
+
+// Reads a button press. Destination orders are entered by pressing hall buttons on two floors.
+func ParseOrder(call, floor string) (types.Order, error) {
+	var order types.Order
+	switch call {
+	case types.HallUp.String():
+		order.C = types.HallUp
+	case types.HallDown.String():
+		order.C = types.HallDown
+	case types.Car.String():
+		order.C = types.Car
+	default:
+		return order, fmt.Errorf("call must be HallUp, HallDown or Car, got %q", call)
+	}
+	if order.C != types.Car && config.DESTINATION_DISPATCH {
+		// A single press would only start an entry on the emulated destination keypad
+		return order, fmt.Errorf("the building takes destination calls, give call Destination and a destination")
+	}
+	f, err := parseFloor("floor", floor)
+	if err != nil {
+		return order, err
+	}
+	order.F = f
+	if (order.C == types.HallUp && f == config.NUMBER_OF_FLOORS-1) || (order.C == types.HallDown && f == 0) {
+		return order, fmt.Errorf("there is no %s button on floor %d", order.C, f)
+	}
+	return order, nil
+}
+
+// Destination order of passengers at the floor, which the building only takes with DESTINATION_DISPATCH
+func ParseDestinationOrder(floor, destination string) (types.Order, error) {
+	order := types.Order{C: types.Destination}
+	if !config.DESTINATION_DISPATCH {
+		return order, fmt.Errorf("destination calls need DESTINATION_DISPATCH")
+	}
+	var err error
+	if order.F, err = parseFloor("floor", floor); err != nil {
+		return order, err
+	}
+	if order.D, err = parseFloor("destination", destination); err != nil {
+		return order, err
+	}
+	if order.D == order.F {
+		return order, fmt.Errorf("destination must differ from the floor %d", order.F)
+	}
+	return order, nil
+}
+
+func parseFloor(name, floor string) (types.Floor, error) {
+	f, err := strconv.Atoi(floor)
+	if err != nil || f < 0 || f >= config.NUMBER_OF_FLOORS {
+		return 0, fmt.Errorf("%s must be between 0 and %d, got %q", name, config.NUMBER_OF_FLOORS-1, floor)
+	}
+	return types.Floor(f), nil
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"project-group-81/config"
+	"project-group-81/network"
+	"project-group-81/types"
+	"sort"
+	"time"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+const (
+	DASHBOARD_PERIOD     = time.Millisecond * 250 // How often the event stream checks the network node for changes
+	DASHBOARD_KEEP_ALIVE = time.Second * 15       // Longest silence on the event stream, so proxies keep it open
+)
+
+// The building as the node sees it, in the form the dashboard draws it
+type Building struct {
+	Floors     int
+	Searching  bool // The node is looking for a master and has nothing to show
+	NodeId     int
+	Role       string
+	MasterId   int
+	Epoch      int
+	Version    int
+	HallLights []types.Order // Hall buttons of the unserved hall and destination orders
+	Boarding   []Boarding    // Which elevator the passengers of each destination order take
+	Shafts     []Shaft
+	Observers  []int // Ids of the active observer nodes, which have no shaft
+}
+
+// Elevator chosen for passengers who entered their destination at the hall
+type Boarding struct {
+	Floor       types.Floor
+	Destination types.Floor
+	Elevator    int
+}
+
+// One elevator, by the process running it
+type Shaft struct {
+	Id        int
+	Address   string
+	Active    bool
+	Master    bool
+	Floor     types.Floor
+	Direction string // "up", "down" or "idle"
+	DoorOpen  bool
+	Orders    []types.Order // Orders the elevator is serving, cab orders included
+	Assigned  []types.Order // Orders the master has assigned to the elevator
+	Floors    []types.Floor // Floors the elevator may stop at, every floor when empty
+	Load      int
+	Blockers  []string // Why the elevator takes no hall calls, empty when it does
+}
+
+// The building as a network node's status shows it
+func NewBuilding(status network.Status) Building {
+	b := Building{
+		Floors:   config.NUMBER_OF_FLOORS,
+		NodeId:   status.Id,
+		Role:     status.Role,
+		MasterId: status.MasterId,
+		Epoch:    status.Epoch,
+		Version:  status.Version,
+	}
+	lit := make(types.OrderSet)
+	for _, assigned := range status.AssignedOrders {
+		if assigned.Order.C != types.Car {
+			lit.Insert(assigned.Order.Button())
+		}
+	}
+	b.HallLights = sortedOrders(lit)
+	b.Boarding = []Boarding{}
+	for _, assigned := range status.AssignedOrders {
+		if assigned.Order.C == types.Destination {
+			b.Boarding = append(b.Boarding, Boarding{assigned.Order.F, assigned.Order.D, assigned.Id})
+		}
+	}
+	sort.Slice(b.Boarding, func(i, j int) bool {
+		if b.Boarding[i].Floor != b.Boarding[j].Floor {
+			return b.Boarding[i].Floor < b.Boarding[j].Floor
+		}
+		return b.Boarding[i].Destination < b.Boarding[j].Destination
+	})
+	b.Observers = []int{}
+	for _, p := range status.Processes {
+		if p.Observer {
+			if p.Active {
+				b.Observers = append(b.Observers, p.Id)
+			}
+			continue
+		}
+		e := p.Elevator
+		s := Shaft{
+			Id:        p.Id,
+			Address:   p.Socket.String(),
+			Active:    p.Active,
+			Master:    p.Id == status.MasterId,
+			Floor:     e.LastFloor,
+			Direction: "idle",
+			DoorOpen:  e.State == types.Carring,
+			Orders:    sortedOrders(e.Orders),
+			Assigned:  []types.Order{},
+			Floors:    e.Floors,
+			Load:      e.Load,
+			Blockers:  e.HallOrderBlockers(),
+		}
+		if e.State == types.Moving && e.LastDirection == types.MotorUp {
+			s.Direction = "up"
+		} else if e.State == types.Moving && e.LastDirection == types.MotorDown {
+			s.Direction = "down"
+		}
+		for _, assigned := range status.AssignedOrders {
+			if assigned.Id == p.Id {
+				s.Assigned = append(s.Assigned, assigned.Order)
+			}
+		}
+		b.Shafts = append(b.Shafts, s)
+	}
+	sort.Slice(b.Shafts, func(i, j int) bool {
+		return b.Shafts[i].Id < b.Shafts[j].Id
+	})
+	return b
+}
+
+// Orders of the set by floor and call, so unchanged sets give unchanged events
+func sortedOrders(orders types.OrderSet) []types.Order {
+	sorted := []types.Order{}
+	for o := range orders {
+		sorted = append(sorted, o)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.F != b.F {
+			return a.F < b.F
+		}
+		if a.C != b.C {
+			return a.C < b.C
+		}
+		return a.D < b.D
+	})
+	return sorted
+}
+
+// The building now, or a searching building if the node does not answer
+func (s server) building() Building {
+	reply := make(chan network.Status, 1)
+	select {
+	case s.statusRequestChan <- reply:
+		return NewBuilding(<-reply)
+	case <-time.After(REQUEST_TIMEOUT):
+		return Building{Floors: config.NUMBER_OF_FLOORS, Searching: true, MasterId: -1}
+	}
+}
+
+func (s server) dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+func (s server) buildingJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.building())
+}
+
+// Streams the building as Server-Sent Events, sending it again whenever it changes
+func (s server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(DASHBOARD_PERIOD)
+	defer ticker.Stop()
+	var last []byte
+	lastSent := time.Now()
+	for {
+		data, err := json.Marshal(s.building())
+		if err != nil {
+			log.Error("Failed to marshal building", "err", err)
+			return
+		}
+		if string(data) != string(last) {
+			_, err = fmt.Fprintf(w, "event: building\ndata: %s\n\n", data)
+			last = data
+			lastSent = time.Now()
+		} else if time.Since(lastSent) >= DASHBOARD_KEEP_ALIVE {
+			_, err = fmt.Fprintf(w, ": keep-alive\n\n")
+			lastSent = time.Now()
+		}
+		if err != nil {
+			log.Debug("Dashboard client left", "client", r.RemoteAddr, "err", err)
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
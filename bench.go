@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"project-group-81/benchmark"
+	"project-group-81/network"
+	"strings"
+	"time"
+)
+
+type benchmarkFlags struct {
+	elevators  *int
+	profile    *string
+	rate       *float64
+	duration   *time.Duration
+	seed       *int64
+	strategies *string
+	format     *string
+}
+
+func newBenchmarkFlags(flags *flag.FlagSet) benchmarkFlags {
+	return benchmarkFlags{
+		elevators:  flags.Int("elevators", 3, ""),
+		profile:    flags.String("profile", "up-peak", ""),
+		rate:       flags.Float64("rate", 6, ""),
+		duration:   flags.Duration("duration", time.Hour, ""),
+		seed:       flags.Int64("seed", 1, ""),
+		strategies: flags.String("strategies", strings.Join(network.Strategies(), ","), ""),
+		format:     flags.String("format", "table", ""),
+	}
+}
+
+// Runs every strategy on the same generated traffic and prints one line of statistics per strategy
+func RunBenchmark(b benchmarkFlags) error {
+	if *b.elevators < 1 {
+		return fmt.Errorf("need at least one elevator, got %d", *b.elevators)
+	}
+	if *b.format != "table" && *b.format != "csv" {
+		return fmt.Errorf("unknown output format %q, expected table or csv", *b.format)
+	}
+	passengers, err := benchmark.Generate(*b.profile, *b.rate, *b.duration, *b.seed)
+	if err != nil {
+		return err
+	}
+
+	// Leave time for everyone to arrive, but stop if a strategy leaves passengers behind for good
+	limit := 2 * *b.duration
+	var statistics []benchmark.Statistics
+	for _, strategy := range strings.Split(*b.strategies, ",") {
+		outcome, err := benchmark.Simulate(strings.TrimSpace(strategy), *b.elevators, passengers, limit)
+		if err != nil {
+			return err
+		}
+		statistics = append(statistics, outcome.Statistics())
+	}
+
+	if *b.format == "csv" {
+		return benchmark.WriteCSV(os.Stdout, statistics)
+	}
+	fmt.Printf("%d passengers, %s traffic at %v per minute for %v with %d elevators. Times in seconds.\n",
+		len(passengers), *b.profile, *b.rate, *b.duration, *b.elevators)
+	return benchmark.WriteTable(os.Stdout, statistics)
+}
@@ -0,0 +1,125 @@
+package benchmark
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Summary of one strategy. Waiting is from the hall call until boarding, journey from the hall call
+// until arrival at the destination. Passengers that never boarded or arrived are left out of those.
+type Statistics struct {
+	Strategy   string
+	Passengers int
+	Delivered  int
+	Wait       Distribution
+	Journey    Distribution
+	Stops      int
+	Energy     int // Floors travelled plus START_ENERGY for every motor start
+}
+
+type Distribution struct {
+	Mean, P50, P90, P99, Max time.Duration
+}
+
+func (o Outcome) Statistics() Statistics {
+	var waits, journeys []time.Duration
+	delivered := 0
+	for _, t := range o.trips {
+		if t.hasBoarded {
+			waits = append(waits, t.boarded-t.Arrival)
+		}
+		if t.hasDelivered {
+			journeys = append(journeys, t.delivered-t.Arrival)
+			delivered++
+		}
+	}
+	return Statistics{
+		Strategy:   o.Strategy,
+		Passengers: len(o.trips),
+		Delivered:  delivered,
+		Wait:       distribution(waits),
+		Journey:    distribution(journeys),
+		Stops:      o.stops,
+		Energy:     o.floors + START_ENERGY*o.starts,
+	}
+}
+
+func distribution(samples []time.Duration) Distribution {
+	if len(samples) == 0 {
+		return Distribution{}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+	}
+	return Distribution{
+		Mean: total / time.Duration(len(samples)),
+		P50:  percentile(samples, 50),
+		P90:  percentile(samples, 90),
+		P99:  percentile(samples, 99),
+		Max:  samples[len(samples)-1],
+	}
+}
+
+// Nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+var columns = []string{
+	"strategy", "passengers", "delivered",
+	"wait_mean", "wait_p50", "wait_p90", "wait_p99", "wait_max",
+	"journey_mean", "journey_p50", "journey_p90", "journey_p99", "journey_max",
+	"stops", "energy",
+}
+
+// One row per strategy, with times in seconds
+func (s Statistics) row(precision int) []string {
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'f', precision, 64)
+	}
+	return []string{
+		s.Strategy, strconv.Itoa(s.Passengers), strconv.Itoa(s.Delivered),
+		seconds(s.Wait.Mean), seconds(s.Wait.P50), seconds(s.Wait.P90), seconds(s.Wait.P99), seconds(s.Wait.Max),
+		seconds(s.Journey.Mean), seconds(s.Journey.P50), seconds(s.Journey.P90), seconds(s.Journey.P99), seconds(s.Journey.Max),
+		strconv.Itoa(s.Stops), strconv.Itoa(s.Energy),
+	}
+}
+
+func WriteTable(w io.Writer, statistics []Statistics) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, column := range columns {
+		fmt.Fprintf(tw, "%s\t", column)
+	}
+	fmt.Fprintln(tw)
+	for _, s := range statistics {
+		for _, cell := range s.row(1) {
+			fmt.Fprintf(tw, "%s\t", cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func WriteCSV(w io.Writer, statistics []Statistics) error {
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, s := range statistics {
+		cw.Write(s.row(3))
+	}
+	cw.Flush()
+	return cw.Error()
+}
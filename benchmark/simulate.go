@@ -0,0 +1,186 @@
+package benchmark
+
+import (
+	"project-group-81/config"
+	"project-group-81/elevator"
+	"project-group-81/network"
+	"project-group-81/types"
+	"time"
+)
+
+// Energy of starting the motor, in floors travelled. Accelerating costs about as much as a floor of travel.
+const START_ENERGY = 1
+
+// What happened to one passenger
+type trip struct {
+	Passenger
+	boarded, delivered       time.Duration
+	hasBoarded, hasDelivered bool
+}
+
+type car struct {
+	elevator.Elevator
+	next   time.Duration // When the current door cycle or floor travel ends
+	riders []*trip
+	stops  int
+	floors int
+	starts int
+}
+
+// Result of running one strategy on a passenger list
+type Outcome struct {
+	Strategy string
+	trips    []*trip
+	stops    int
+	floors   int
+	starts   int
+}
+
+// Runs the passengers through a fleet of elevators, starting idle at the lobby, that are dispatched by
+// the assigner. Runs until every passenger is delivered, or until limit if some never are.
+func Simulate(strategy string, elevators int, passengers []Passenger, limit time.Duration) (Outcome, error) {
+	assigner, err := network.NewAssigner(strategy)
+	if err != nil {
+		return Outcome{}, err
+	}
+	cars := make([]*car, elevators)
+	for i := range cars {
+		cars[i] = &car{Elevator: elevator.DefaultElevator()}
+	}
+	outcome := Outcome{Strategy: strategy}
+	waiting := make(map[types.Order][]*trip)
+	remaining := len(passengers)
+
+	for now, arrivals := time.Duration(0), passengers; remaining > 0 && now <= limit; {
+		// Passengers arriving now press their hall button, then every car due now moves on
+		for len(arrivals) > 0 && arrivals[0].Arrival <= now {
+			t := &trip{Passenger: arrivals[0]}
+			arrivals = arrivals[1:]
+			outcome.trips = append(outcome.trips, t)
+			call := t.Call()
+			waiting[call] = append(waiting[call], t)
+			if !served(cars, call) {
+				assigned := assigner.Assign(call, processes(cars))
+				cars[assigned.Id].take(call, now)
+			}
+			for _, c := range cars {
+				if c.State == types.Carring && c.LastFloor == call.F {
+					c.board(now, waiting)
+				}
+			}
+		}
+		for _, c := range cars {
+			if c.State == types.Standby || c.next > now {
+				continue
+			}
+			switch c.State {
+			case types.Moving:
+				c.Arrive()
+				c.floors++
+				if c.State == types.Carring {
+					c.stops++
+				}
+			case types.Carring:
+				c.CloseDoor()
+				if c.State == types.Moving {
+					c.starts++
+				}
+			}
+			switch c.State {
+			case types.Moving:
+				c.next = now + config.FLOOR_TRAVEL_TIME
+			case types.Carring:
+				c.next = now + config.DOOR_OPEN_TIME
+				remaining -= c.alight(now)
+				c.board(now, waiting)
+			}
+		}
+
+		// Skip ahead to the next arrival or car event
+		next := limit + 1
+		if len(arrivals) > 0 {
+			next = arrivals[0].Arrival
+		}
+		for _, c := range cars {
+			if c.State != types.Standby && c.next < next {
+				next = c.next
+			}
+		}
+		now = next
+	}
+	for _, c := range cars {
+		outcome.stops += c.stops
+		outcome.floors += c.floors
+		outcome.starts += c.starts
+	}
+	return outcome, nil
+}
+
+// Whether a car already has the hall order, so pressing the button again has no effect
+func served(cars []*car, call types.Order) bool {
+	for _, c := range cars {
+		if c.Orders.Contains(call) {
+			return true
+		}
+	}
+	return false
+}
+
+// The fleet as the master sees it
+func processes(cars []*car) []network.Process {
+	processes := make([]network.Process, len(cars))
+	for i, c := range cars {
+		processes[i] = network.Process{Id: i, Active: true, Elevator: c.Elevator}
+	}
+	return processes
+}
+
+func (c *car) take(order types.Order, now time.Duration) {
+	wasStandby := c.State == types.Standby
+	c.Take(order)
+	if !wasStandby {
+		return
+	}
+	switch c.State {
+	case types.Moving:
+		c.starts++
+		c.next = now + config.FLOOR_TRAVEL_TIME
+	case types.Carring:
+		c.stops++
+		c.next = now + config.DOOR_OPEN_TIME
+	}
+}
+
+// Lets out the riders who have arrived, returning how many they are
+func (c *car) alight(now time.Duration) int {
+	delivered := 0
+	riders := c.riders[:0]
+	for _, t := range c.riders {
+		if t.To == c.LastFloor {
+			t.delivered = now
+			t.hasDelivered = true
+			delivered++
+		} else {
+			riders = append(riders, t)
+		}
+	}
+	c.riders = riders
+	return delivered
+}
+
+// Lets in the waiting passengers whose direction the car announces. Passengers only enter the car
+// that has their call, like they would only enter an elevator going their way.
+func (c *car) board(now time.Duration, waiting map[types.Order][]*trip) {
+	for _, call := range []types.Order{{C: types.HallUp, F: c.LastFloor}, {C: types.HallDown, F: c.LastFloor}} {
+		if len(waiting[call]) == 0 || !c.IsStoppingFor(call) {
+			continue
+		}
+		for _, t := range waiting[call] {
+			t.boarded = now
+			t.hasBoarded = true
+			c.riders = append(c.riders, t)
+			c.Take(types.Order{C: types.Car, F: t.To})
+		}
+		delete(waiting, call)
+	}
+}
@@ -0,0 +1,176 @@
+package benchmark
+
+import (
+	"fmt"
+	"math/rand"
+	"project-group-81/config"
+	"project-group-81/types"
+	"sort"
+	"time"
+)
+
+// A person calling an elevator at From to travel to To
+type Passenger struct {
+	Arrival time.Duration // Time since the start of the simulation
+	From    types.Floor
+	To      types.Floor
+}
+
+// Hall call the passenger presses when arriving
+func (p Passenger) Call() types.Order {
+	if p.To > p.From {
+		return types.Order{C: types.HallUp, F: p.From}
+	}
+	return types.Order{C: types.HallDown, F: p.From}
+}
+
+// Relative arrival rate per floor, and for each origin floor the relative chance of every destination
+type traffic struct {
+	origin      func(floor types.Floor) float64
+	destination func(from, to types.Floor) float64
+}
+
+// Most passengers enter at the lobby and travel up
+var upPeak = traffic{
+	origin: func(floor types.Floor) float64 {
+		if floor == config.LOBBY_FLOOR {
+			return 8
+		}
+		return 1
+	},
+	destination: func(from, to types.Floor) float64 {
+		if from != config.LOBBY_FLOOR && to == config.LOBBY_FLOOR {
+			return 1
+		} else if from != config.LOBBY_FLOOR {
+			return 0.5
+		}
+		return 1
+	},
+}
+
+// Most passengers leave from the upper floors towards the lobby
+var downPeak = traffic{
+	origin: func(floor types.Floor) float64 {
+		if floor == config.LOBBY_FLOOR {
+			return 1
+		}
+		return 3
+	},
+	destination: func(from, to types.Floor) float64 {
+		if to == config.LOBBY_FLOOR {
+			return 8
+		}
+		return 1
+	},
+}
+
+// Passengers arrive at and travel between every floor with the same rate
+var interFloor = traffic{
+	origin: func(floor types.Floor) float64 {
+		return 1
+	},
+	destination: func(from, to types.Floor) float64 {
+		return 1
+	},
+}
+
+// Traffic of each profile over the course of the simulation, as a function of the elapsed fraction
+var profiles = map[string]func(elapsed float64) traffic{
+	"up-peak":     func(float64) traffic { return upPeak },
+	"down-peak":   func(float64) traffic { return downPeak },
+	"inter-floor": func(float64) traffic { return interFloor },
+	"day": func(elapsed float64) traffic { // Morning up-peak, inter-floor traffic and afternoon down-peak
+		if elapsed < 1.0/3 {
+			return upPeak
+		} else if elapsed < 2.0/3 {
+			return interFloor
+		}
+		return downPeak
+	},
+}
+
+// Names of every available traffic profile
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generates passengers arriving as independent Poisson processes per floor, sorted by arrival time.
+// Rate is the mean number of passengers per minute for the whole building.
+func Generate(profile string, rate float64, duration time.Duration, seed int64) ([]Passenger, error) {
+	trafficAt, found := profiles[profile]
+	if !found {
+		return nil, fmt.Errorf("unknown traffic profile %q, expected one of %v", profile, Profiles())
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("arrival rate must be positive, got %v", rate)
+	}
+	random := rand.New(rand.NewSource(seed))
+
+	// Thinning: candidate arrivals at the highest possible floor rate, kept with probability rate/maximum
+	var passengers []Passenger
+	for from := 0; from < config.NUMBER_OF_FLOORS; from++ {
+		maximum := 0.0
+		for _, elapsed := range []float64{0, 0.5, 1} {
+			if r := floorRate(trafficAt(elapsed), from, rate); r > maximum {
+				maximum = r
+			}
+		}
+		if maximum == 0 {
+			continue
+		}
+		t := 0.0
+		for {
+			t += random.ExpFloat64() / maximum
+			arrival := time.Duration(t * float64(time.Minute))
+			if arrival >= duration {
+				break
+			}
+			current := trafficAt(float64(arrival) / float64(duration))
+			if random.Float64()*maximum >= floorRate(current, from, rate) {
+				continue
+			}
+			passengers = append(passengers, Passenger{arrival, from, destination(current, from, random)})
+		}
+	}
+	sort.SliceStable(passengers, func(i, j int) bool {
+		return passengers[i].Arrival < passengers[j].Arrival
+	})
+	return passengers, nil
+}
+
+// Passengers per minute arriving at the floor
+func floorRate(t traffic, floor types.Floor, rate float64) float64 {
+	total := 0.0
+	for f := 0; f < config.NUMBER_OF_FLOORS; f++ {
+		total += t.origin(f)
+	}
+	return rate * t.origin(floor) / total
+}
+
+func destination(t traffic, from types.Floor, random *rand.Rand) types.Floor {
+	total := 0.0
+	for to := 0; to < config.NUMBER_OF_FLOORS; to++ {
+		if to != from {
+			total += t.destination(from, to)
+		}
+	}
+	pick := random.Float64() * total
+	for to := 0; to < config.NUMBER_OF_FLOORS; to++ {
+		if to == from {
+			continue
+		}
+		pick -= t.destination(from, to)
+		if pick < 0 {
+			return to
+		}
+	}
+	if from == config.NUMBER_OF_FLOORS-1 {
+		return from - 1
+	}
+	return config.NUMBER_OF_FLOORS - 1
+}
@@ -0,0 +1,245 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"project-group-81/api"
+	"project-group-81/config"
+	"project-group-81/elevator"
+	"project-group-81/logging"
+	"project-group-81/mqtt"
+	"project-group-81/network"
+	"project-group-81/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var log = logging.For("bridge")
+
+const (
+	PUBLISH_PERIOD   = time.Millisecond * 500 // How often the bridge checks the network node for changes to publish
+	RECONNECT_PERIOD = time.Second * 5        // Time between attempts to reach the broker
+	REQUEST_TIMEOUT  = time.Second * 2        // Time to wait for the elevator or network node to answer
+)
+
+// Alarms published for every car, by their name in the alarm topic
+var alarms = []struct {
+	name   string
+	active func(elevator.Health) bool
+}{
+	{"stop_pressed", func(h elevator.Health) bool { return h.StopPressed }},
+	{"motor_fault", func(h elevator.Health) bool { return h.MotorFault }},
+	{"obstructed", func(h elevator.Health) bool { return h.Obstructed }},
+}
+
+// Payload of the alarm topics
+type Alarm struct {
+	Car    int
+	Alarm  string
+	Active bool
+}
+
+// Payload of the hall call topic
+type HallCall struct {
+	Call        string // HallUp or HallDown, or Destination with DESTINATION_DISPATCH
+	Floor       int
+	Destination int // Only for Destination calls
+}
+
+// Command received from the broker
+type command struct {
+	order     *types.Order // Hall call to place, handled by the master
+	car       int          // Car to take out of or back into service, handled by its own node
+	inService bool
+}
+
+// Keeps the bridge running, connecting to the broker again after RECONNECT_PERIOD whenever the connection
+// fails. Tests may dial an mqtt.LocalBroker instead of a real broker.
+func Serve(
+	dial func() (mqtt.Client, error),
+	statusRequestChan chan<- chan network.Status,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) {
+
+	for {
+		client, err := dial()
+		if err != nil {
+			log.Warn("Failed to connect to MQTT broker", "err", err)
+		} else {
+			log.Info("Connected to MQTT broker")
+			err = Run(client, statusRequestChan, remoteButtonChan, serviceChan)
+			client.Close()
+			log.Warn("Lost MQTT broker", "err", err)
+		}
+		time.Sleep(RECONNECT_PERIOD)
+	}
+}
+
+// Publishes the building while the node is master and carries out commands until the connection is lost.
+// Only the master publishes and places hall calls, so every node may run a bridge without duplicates.
+// Service commands are carried out by the node of the car they name.
+func Run(
+	client mqtt.Client,
+	statusRequestChan chan<- chan network.Status,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) error {
+
+	commandChan := make(chan command, 16)
+	queue := func(c command) {
+		select {
+		case commandChan <- c:
+		default:
+			log.Warn("Too many MQTT commands waiting, dropping one")
+		}
+	}
+	err := client.Subscribe(config.MQTT_HALL_CALL_TOPIC, func(topic string, payload []byte) {
+		order, err := parseHallCall(payload)
+		if err != nil {
+			log.Warn("Ignoring invalid hall call", "topic", topic, "payload", string(payload), "err", err)
+			return
+		}
+		queue(command{order: &order})
+	})
+	if err != nil {
+		return err
+	}
+	serviceFilter, serviceCar := carTopic(config.MQTT_SERVICE_TOPIC)
+	err = client.Subscribe(serviceFilter, func(topic string, payload []byte) {
+		car, found := serviceCar(topic)
+		if !found {
+			return
+		}
+		switch strings.TrimSpace(string(payload)) {
+		case "out":
+			queue(command{car: car, inService: false})
+		case "in":
+			queue(command{car: car, inService: true})
+		default:
+			log.Warn("Ignoring service command, expected \"in\" or \"out\"", "topic", topic, "payload", string(payload))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	published := make(map[string]string) // Payload last published on each topic, so unchanged ones are not sent again
+	ticker := time.NewTicker(PUBLISH_PERIOD)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-client.Lost():
+			return err
+		case <-ticker.C:
+			status, known := requestStatus(statusRequestChan)
+			if !known || status.Role != "master" {
+				continue
+			}
+			if err := publishBuilding(client, status, published); err != nil {
+				return err
+			}
+		case c := <-commandChan:
+			status, known := requestStatus(statusRequestChan)
+			if !known {
+				log.Warn("Ignoring MQTT command while searching for a master")
+			} else if c.order != nil && status.Role == "master" {
+				log.Info("Hall call from MQTT", "order", *c.order)
+				select {
+				case remoteButtonChan <- *c.order:
+				case <-time.After(REQUEST_TIMEOUT):
+					log.Warn("Elevator is not responding, dropping hall call", "order", *c.order)
+				}
+			} else if c.order == nil && c.car == status.Id && status.Role != "observer" {
+				log.Info("Service command from MQTT", "in_service", c.inService)
+				select {
+				case serviceChan <- c.inService:
+				case <-time.After(REQUEST_TIMEOUT):
+					log.Warn("Elevator is not responding, dropping service command")
+				}
+			}
+		}
+	}
+}
+
+// The status of the network node, which does not answer while it is searching for a master
+func requestStatus(statusRequestChan chan<- chan network.Status) (network.Status, bool) {
+	reply := make(chan network.Status, 1)
+	select {
+	case statusRequestChan <- reply:
+		return <-reply, true
+	case <-time.After(REQUEST_TIMEOUT):
+		return network.Status{}, false
+	}
+}
+
+func parseHallCall(payload []byte) (types.Order, error) {
+	var call HallCall
+	if err := json.Unmarshal(payload, &call); err != nil {
+		return types.Order{}, err
+	}
+	if call.Call == types.Destination.String() {
+		return api.ParseDestinationOrder(strconv.Itoa(call.Floor), strconv.Itoa(call.Destination))
+	}
+	if call.Call != types.HallUp.String() && call.Call != types.HallDown.String() {
+		return types.Order{}, fmt.Errorf("call must be HallUp, HallDown or Destination, got %q", call.Call)
+	}
+	return api.ParseOrder(call.Call, strconv.Itoa(call.Floor))
+}
+
+// Publishes the topics whose payload changed, all of them retained
+func publishBuilding(client mqtt.Client, status network.Status, published map[string]string) error {
+	topics := make(map[string]interface{})
+	building := api.NewBuilding(status)
+	topics[config.MQTT_HALL_LIGHTS_TOPIC] = building.HallLights
+	for _, shaft := range building.Shafts {
+		topics[carTopicName(config.MQTT_STATE_TOPIC, shaft.Id, "")] = shaft
+	}
+	for _, p := range status.Processes {
+		if p.Observer {
+			continue
+		}
+		for _, alarm := range alarms {
+			topics[carTopicName(config.MQTT_ALARM_TOPIC, p.Id, alarm.name)] = Alarm{p.Id, alarm.name, alarm.active(p.Elevator.Health)}
+		}
+	}
+
+	for topic, value := range topics {
+		payload, err := json.Marshal(value)
+		if err != nil {
+			log.Error("Failed to marshal MQTT payload", "topic", topic, "err", err)
+			continue
+		}
+		if published[topic] == string(payload) {
+			continue
+		}
+		if err := client.Publish(topic, payload, true); err != nil {
+			return err
+		}
+		published[topic] = string(payload)
+	}
+	return nil
+}
+
+func carTopicName(template string, car int, alarm string) string {
+	return strings.NewReplacer("{car}", strconv.Itoa(car), "{alarm}", alarm).Replace(template)
+}
+
+// The filter matching the topic of every car, and how to find the car in a topic matching it
+func carTopic(template string) (string, func(topic string) (int, bool)) {
+	levels := strings.Split(template, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "{car}") {
+			levels[i] = "+"
+		}
+	}
+	pattern := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(template), regexp.QuoteMeta("{car}"), `(\d+)`) + "$")
+	return strings.Join(levels, "/"), func(topic string) (int, bool) {
+		match := pattern.FindStringSubmatch(topic)
+		if match == nil {
+			return 0, false
+		}
+		car, err := strconv.Atoi(match[1])
+		return car, err == nil
+	}
+}
@@ -0,0 +1,232 @@
+package bridge
+
+import (
+	"encoding/json"
+	"project-group-81/api"
+	"project-group-81/config"
+	"project-group-81/elevator"
+	"project-group-81/mqtt"
+	"project-group-81/network"
+	"project-group-81/types"
+	"testing"
+	"time"
+)
+
+var hallUp = types.Order{C: types.HallUp, F: 2}
+
+// A building with a working car 0, car 1 with its stop button pressed and observer 2
+func testStatus(role string, id int) network.Status {
+	stopped := elevator.DefaultElevator()
+	stopped.Health.StopPressed = true
+	return network.Status{
+		Role: role,
+		Id:   id,
+		Processes: []network.Process{
+			{Id: 0, Active: true, Elevator: elevator.DefaultElevator()},
+			{Id: 1, Active: true, Elevator: stopped},
+			{Id: 2, Active: true, Elevator: elevator.DefaultElevator(), Observer: true}},
+		AssignedOrders: []network.AssignedOrder{{Id: 0, Order: hallUp}},
+	}
+}
+
+// Runs the bridge on a new client of the broker, answering status requests like a network node would
+func runBridge(t *testing.T, broker *mqtt.LocalBroker, status network.Status) (<-chan types.Order, <-chan bool) {
+	statusRequestChan := make(chan chan network.Status)
+	remoteButtonChan := make(chan types.Order, 4)
+	serviceChan := make(chan bool, 4)
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case reply := <-statusRequestChan:
+				reply <- status
+			case <-done:
+				return
+			}
+		}
+	}()
+	client := broker.Client()
+	go Run(client, statusRequestChan, remoteButtonChan, serviceChan)
+	t.Cleanup(func() {
+		client.Close() // Ends the bridge at its next publish
+		close(done)
+	})
+	return remoteButtonChan, serviceChan
+}
+
+func waitRetained(t *testing.T, broker *mqtt.LocalBroker, topic string, value interface{}) {
+	t.Helper()
+	deadline := time.Now().Add(PUBLISH_PERIOD * 4)
+	for time.Now().Before(deadline) {
+		if payload, found := broker.Retained(topic); found {
+			if err := json.Unmarshal(payload, value); err != nil {
+				t.Fatalf("%s: %v", topic, err)
+			}
+			return
+		}
+		time.Sleep(PUBLISH_PERIOD / 10)
+	}
+	t.Fatalf("nothing retained on %s", topic)
+}
+
+func TestMasterPublishesRetainedBuilding(t *testing.T) {
+	broker := mqtt.NewLocalBroker()
+	runBridge(t, broker, testStatus("master", 0))
+
+	for _, car := range []int{0, 1} {
+		var shaft api.Shaft
+		waitRetained(t, broker, carTopicName("elevators/car/{car}/state", car, ""), &shaft)
+		if shaft.Id != car || !shaft.Active {
+			t.Errorf("state of car %d: got %+v", car, shaft)
+		}
+	}
+	if _, found := broker.Retained("elevators/car/2/state"); found {
+		t.Error("state published for an observer")
+	}
+
+	var lights []types.Order
+	waitRetained(t, broker, "elevators/hall/lights", &lights)
+	if len(lights) != 1 || lights[0] != hallUp {
+		t.Errorf("hall lights: got %v, want [%v]", lights, hallUp)
+	}
+
+	for _, c := range []struct {
+		car    int
+		alarm  string
+		active bool
+	}{
+		{0, "stop_pressed", false},
+		{0, "motor_fault", false},
+		{1, "stop_pressed", true},
+		{1, "obstructed", false},
+	} {
+		var alarm Alarm
+		waitRetained(t, broker, carTopicName("elevators/car/{car}/alarm/{alarm}", c.car, c.alarm), &alarm)
+		if alarm != (Alarm{c.car, c.alarm, c.active}) {
+			t.Errorf("alarm %s of car %d: got %+v, want active %v", c.alarm, c.car, alarm, c.active)
+		}
+	}
+	if _, found := broker.Retained("elevators/car/2/alarm/stop_pressed"); found {
+		t.Error("alarm published for an observer")
+	}
+}
+
+func TestSlaveDoesNotPublish(t *testing.T) {
+	broker := mqtt.NewLocalBroker()
+	runBridge(t, broker, testStatus("slave", 1))
+	time.Sleep(PUBLISH_PERIOD * 3)
+	if _, found := broker.Retained("elevators/hall/lights"); found {
+		t.Error("slave published the hall lights")
+	}
+}
+
+func TestMasterPlacesHallCalls(t *testing.T) {
+	broker := mqtt.NewLocalBroker()
+	remoteButtonChan, _ := runBridge(t, broker, testStatus("master", 0))
+	time.Sleep(PUBLISH_PERIOD / 10) // Let the bridge subscribe
+	publisher := broker.Client()
+	defer publisher.Close()
+
+	for _, payload := range []string{
+		`{"Call": "Car", "Floor": 1}`,
+		`{"Call": "HallUp", "Floor": 99}`,
+		`not json`,
+		`{"Call": "HallDown", "Floor": 1}`,
+	} {
+		if err := publisher.Publish("elevators/hall/call", []byte(payload), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case order := <-remoteButtonChan:
+		if want := (types.Order{C: types.HallDown, F: 1}); order != want {
+			t.Errorf("got %v, want %v", order, want)
+		}
+	case <-time.After(REQUEST_TIMEOUT):
+		t.Fatal("no hall call placed")
+	}
+}
+
+func TestDestinationCalls(t *testing.T) {
+	config.DESTINATION_DISPATCH = true
+	defer func() { config.DESTINATION_DISPATCH = false }()
+	broker := mqtt.NewLocalBroker()
+	remoteButtonChan, _ := runBridge(t, broker, testStatus("master", 0))
+	time.Sleep(PUBLISH_PERIOD / 10)
+	publisher := broker.Client()
+	defer publisher.Close()
+
+	for _, payload := range []string{
+		`{"Call": "HallUp", "Floor": 1}`, // Would only start a keypad entry
+		`{"Call": "Destination", "Floor": 1, "Destination": 1}`,
+		`{"Call": "Destination", "Floor": 1, "Destination": 3}`,
+	} {
+		if err := publisher.Publish("elevators/hall/call", []byte(payload), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case order := <-remoteButtonChan:
+		if want := (types.Order{C: types.Destination, F: 1, D: 3}); order != want {
+			t.Errorf("got %v, want %v", order, want)
+		}
+	case <-time.After(REQUEST_TIMEOUT):
+		t.Fatal("no destination call placed")
+	}
+}
+
+func TestServiceCommandsGoToTheirCar(t *testing.T) {
+	broker := mqtt.NewLocalBroker()
+	remoteButtonChan, serviceChan := runBridge(t, broker, testStatus("slave", 1))
+	time.Sleep(PUBLISH_PERIOD / 10)
+	publisher := broker.Client()
+	defer publisher.Close()
+
+	publish := func(topic, payload string) {
+		if err := publisher.Publish(topic, []byte(payload), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publish("elevators/hall/call", `{"Call": "HallUp", "Floor": 1}`) // Left to the master
+	publish("elevators/car/0/service", "out")                        // Another node's car
+	publish("elevators/car/1/service", "sideways")
+	publish("elevators/car/1/service", "out")
+	publish("elevators/car/1/service", "in")
+
+	for _, want := range []bool{false, true} {
+		select {
+		case inService := <-serviceChan:
+			if inService != want {
+				t.Errorf("got in service %v, want %v", inService, want)
+			}
+		case <-time.After(REQUEST_TIMEOUT):
+			t.Fatalf("no service command, want in service %v", want)
+		}
+	}
+	select {
+	case order := <-remoteButtonChan:
+		t.Errorf("slave placed hall call %v", order)
+	default:
+	}
+}
+
+func TestCarTopic(t *testing.T) {
+	filter, car := carTopic("elevators/car/{car}/service")
+	if filter != "elevators/car/+/service" {
+		t.Errorf("filter: got %q", filter)
+	}
+	for _, c := range []struct {
+		topic string
+		car   int
+		found bool
+	}{
+		{"elevators/car/3/service", 3, true},
+		{"elevators/car/12/service", 12, true},
+		{"elevators/car/x/service", 0, false},
+		{"elevators/car/3/state", 0, false},
+	} {
+		if got, found := car(c.topic); got != c.car || found != c.found {
+			t.Errorf("%s: got %d, %v, want %d, %v", c.topic, got, found, c.car, c.found)
+		}
+	}
+}
@@ -7,3 +7,76 @@ const (
 	DOOR_OPEN_TIME   = time.Second * 3
 	INACTIVE_TIME    = time.Second * 10
 )
+
+// Settings that can be overridden at startup, see Load
+var (
+	HEARTBEAT_PERIOD = time.Millisecond * 200 // Period between heartbeats on every master-slave connection
+	SUSPECT_TIMEOUT  = time.Second            // Silence before a peer is suspected to be dead
+
+	// Nodes use an IPv4 address of the interface, or an IPv6 one if it has no IPv4 address or DISCOVERY_GROUP
+	// is an IPv6 group. IPv6 addresses must be global or unique local, link-local ones are not used.
+	NETWORK_INTERFACE     = ""                     // Interface used for the cluster network. Empty selects one automatically
+	INTERFACE_POLL_PERIOD = time.Millisecond * 500 // Period between link state checks
+
+	CLUSTER_ID          = "elevators"     // Beacons from masters of other clusters are ignored
+	DISCOVERY_TRANSPORT = "broadcast"     // How the master announces itself: "broadcast" (IPv4 only), "multicast" or "static"
+	DISCOVERY_PORT      = "2137"          // UDP port beacons are sent to (the Polish pope death port)
+	DISCOVERY_GROUP     = "239.255.21.37" // IPv4 or IPv6 group used by the multicast transport, e.g. "ff02::2137"
+	DISCOVERY_PEERS     = []string{}      // host:port of every node, used by the static transport
+
+	OFFLINE_HALL_POLICY     = "serve"                // Hall calls without other elevators to share them with: "serve" them locally or "refuse" them
+	REFUSED_CALL_FLASH_TIME = time.Millisecond * 500 // How long a refused hall call lights up
+
+	DISPATCH_STRATEGY = "cost" // Hall order assignment strategy, see network.Strategies
+
+	FLOOR_TRAVEL_TIME = time.Second * 2 // Expected travel time between two floors, used when estimating service times
+
+	REOPTIMISE_PERIOD    = time.Second * 5  // Period between reoptimisations of all unserved hall orders
+	REOPTIMISE_MARGIN    = 0.25             // Fraction the cost must drop by before an order is moved
+	REOPTIMISE_HOLD_TIME = time.Second * 10 // Minimum time between two moves of the same order
+
+	OBSTRUCTION_TIMEOUT  = time.Second * 5 // Obstruction lasting longer than this takes the elevator out of hall service
+	HARDWARE_ERROR_LIMIT = 5               // Consecutive hardware errors before the elevator is reported faulty
+
+	DESTINATION_DISPATCH   = false           // Whether passengers enter their destination at the hall instead of pressing up or down
+	DESTINATION_ENTRY_TIME = time.Second * 3 // Time to press the destination floor after the origin floor on the emulated keypad
+
+	PARKING_POLICY      = "none"           // Where idle elevators go: "none", "lobby", "spread" across the building or to floors of high "demand"
+	PARKING_DELAY       = time.Second * 10 // Time an elevator must be idle before it is parked
+	LOBBY_FLOOR         = 0                // Floor idle elevators return to with the lobby policy
+	DEMAND_HISTORY_FILE = ""               // File keeping hall calls per floor and hour across restarts for the demand policy. Empty keeps them in memory
+
+	SERVED_FLOORS = []int{} // Floors this elevator stops at, e.g. [0, 2, 3] for an express car. Empty serves every floor
+
+	LOAD_SENSOR = false // Read the car load from the hardware, which must support the load command
+	FULL_LOAD   = 80    // Load in percent of capacity at which the car counts as full
+
+	API_ADDRESS  = ""    // Address of the HTTP status and control API, e.g. ":8080". Empty disables it
+	GRPC_ADDRESS = ""    // Address of the gRPC API, e.g. ":50051". Empty disables it
+	API_KILL     = false // Serve POST /kill, which ends the node at once. Only for trying out failures in the simulator
+
+	LOG_FORMAT         = "text"              // Log output, "text" or "json"
+	LOG_LEVEL          = "info"              // Lowest level logged: "debug", "info", "warn" or "error"
+	LOG_PACKAGE_LEVELS = map[string]string{} // Levels overriding LOG_LEVEL for single packages, e.g. {"network": "debug"}
+
+	JOURNAL_FILE = "" // Append-only JSON lines file of order events, which several nodes may share. Empty disables it
+
+	MQTT_BROKER            = "" // Address of the MQTT broker of the building management bridge, e.g. "bms.local:1883". Empty disables it
+	MQTT_CLIENT_ID         = "" // Client identifier, "elevator-<IP address>-<hardware port>" when empty
+	MQTT_USERNAME          = "" // Empty connects without credentials
+	MQTT_PASSWORD          = ""
+	MQTT_KEEP_ALIVE        = time.Second * 30                    // Longest silence towards the broker before a ping
+	MQTT_STATE_TOPIC       = "elevators/car/{car}/state"         // Retained state of each car, published by the master
+	MQTT_HALL_LIGHTS_TOPIC = "elevators/hall/lights"             // Retained list of lit hall buttons, published by the master
+	MQTT_ALARM_TOPIC       = "elevators/car/{car}/alarm/{alarm}" // Retained alarm state: stop_pressed, motor_fault or obstructed
+	MQTT_HALL_CALL_TOPIC   = "elevators/hall/call"               // Command topic for hall calls, such as {"call": "HallUp", "floor": 2}
+	MQTT_SERVICE_TOPIC     = "elevators/car/{car}/service"       // Command topic taking a car "out" of or back "in" hall service
+
+	WEBHOOK_URLS        = []string{}      // Endpoints every event is posted to as JSON. Empty disables webhooks
+	WEBHOOK_EVENTS      = []string{}      // Kinds of event to post, e.g. ["master_failover", "node_inactive"]. Empty posts every kind
+	WEBHOOK_OUTBOX_FILE = ""              // File keeping undelivered events across restarts, with the hardware port added to its name. Empty keeps them in memory
+	WEBHOOK_TIMEOUT     = time.Second * 5 // Time to wait for an endpoint to answer
+	WEBHOOK_RETRY_MIN   = time.Second     // Wait before the first retry of a failed delivery, doubled on every further failure
+	WEBHOOK_RETRY_MAX   = time.Minute * 5 // Longest wait between retries
+	WEBHOOK_HALL_WAIT   = time.Minute     // Time a hall call may wait for an elevator before a hall_call_waiting event is posted
+)
@@ -0,0 +1,348 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Durations are written as strings in the configuration file, e.g. "200ms"
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// Every field is optional. Fields left out of the file keep their default value.
+type fileConfig struct {
+	HEARTBEAT_PERIOD        *duration
+	SUSPECT_TIMEOUT         *duration
+	NETWORK_INTERFACE       *string
+	INTERFACE_POLL_PERIOD   *duration
+	CLUSTER_ID              *string
+	DISCOVERY_TRANSPORT     *string
+	DISCOVERY_PORT          *string
+	DISCOVERY_GROUP         *string
+	DISCOVERY_PEERS         *[]string
+	OFFLINE_HALL_POLICY     *string
+	REFUSED_CALL_FLASH_TIME *duration
+	DISPATCH_STRATEGY       *string
+	FLOOR_TRAVEL_TIME       *duration
+	REOPTIMISE_PERIOD       *duration
+	REOPTIMISE_MARGIN       *float64
+	REOPTIMISE_HOLD_TIME    *duration
+	OBSTRUCTION_TIMEOUT     *duration
+	HARDWARE_ERROR_LIMIT    *int
+	DESTINATION_DISPATCH    *bool
+	DESTINATION_ENTRY_TIME  *duration
+	PARKING_POLICY          *string
+	PARKING_DELAY           *duration
+	LOBBY_FLOOR             *int
+	DEMAND_HISTORY_FILE     *string
+	SERVED_FLOORS           *[]int
+	LOAD_SENSOR             *bool
+	FULL_LOAD               *int
+	API_ADDRESS             *string
+	API_KILL                *bool
+	LOG_FORMAT              *string
+	LOG_LEVEL               *string
+	LOG_PACKAGE_LEVELS      *map[string]string
+	JOURNAL_FILE            *string
+	MQTT_BROKER             *string
+	MQTT_CLIENT_ID          *string
+	MQTT_USERNAME           *string
+	MQTT_PASSWORD           *string
+	MQTT_KEEP_ALIVE         *duration
+	MQTT_STATE_TOPIC        *string
+	MQTT_HALL_LIGHTS_TOPIC  *string
+	MQTT_ALARM_TOPIC        *string
+	MQTT_HALL_CALL_TOPIC    *string
+	MQTT_SERVICE_TOPIC      *string
+	GRPC_ADDRESS            *string
+	WEBHOOK_URLS            *[]string
+	WEBHOOK_EVENTS          *[]string
+	WEBHOOK_OUTBOX_FILE     *string
+	WEBHOOK_TIMEOUT         *duration
+	WEBHOOK_RETRY_MIN       *duration
+	WEBHOOK_RETRY_MAX       *duration
+	WEBHOOK_HALL_WAIT       *duration
+}
+
+// Overrides the settings in this package with the ones found in a JSON file
+func Load(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file fileConfig
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+
+	if file.HEARTBEAT_PERIOD != nil {
+		HEARTBEAT_PERIOD = time.Duration(*file.HEARTBEAT_PERIOD)
+	}
+	if file.SUSPECT_TIMEOUT != nil {
+		SUSPECT_TIMEOUT = time.Duration(*file.SUSPECT_TIMEOUT)
+	}
+	if file.NETWORK_INTERFACE != nil {
+		NETWORK_INTERFACE = *file.NETWORK_INTERFACE
+	}
+	if file.INTERFACE_POLL_PERIOD != nil {
+		INTERFACE_POLL_PERIOD = time.Duration(*file.INTERFACE_POLL_PERIOD)
+	}
+	if file.CLUSTER_ID != nil {
+		CLUSTER_ID = *file.CLUSTER_ID
+	}
+	if file.DISCOVERY_TRANSPORT != nil {
+		DISCOVERY_TRANSPORT = *file.DISCOVERY_TRANSPORT
+	}
+	if file.DISCOVERY_PORT != nil {
+		DISCOVERY_PORT = *file.DISCOVERY_PORT
+	}
+	if file.DISCOVERY_GROUP != nil {
+		DISCOVERY_GROUP = *file.DISCOVERY_GROUP
+	}
+	if file.DISCOVERY_PEERS != nil {
+		DISCOVERY_PEERS = *file.DISCOVERY_PEERS
+	}
+	if file.OFFLINE_HALL_POLICY != nil {
+		OFFLINE_HALL_POLICY = *file.OFFLINE_HALL_POLICY
+	}
+	if file.REFUSED_CALL_FLASH_TIME != nil {
+		REFUSED_CALL_FLASH_TIME = time.Duration(*file.REFUSED_CALL_FLASH_TIME)
+	}
+	if file.DISPATCH_STRATEGY != nil {
+		DISPATCH_STRATEGY = *file.DISPATCH_STRATEGY
+	}
+	if file.FLOOR_TRAVEL_TIME != nil {
+		FLOOR_TRAVEL_TIME = time.Duration(*file.FLOOR_TRAVEL_TIME)
+	}
+	if file.REOPTIMISE_PERIOD != nil {
+		REOPTIMISE_PERIOD = time.Duration(*file.REOPTIMISE_PERIOD)
+	}
+	if file.REOPTIMISE_MARGIN != nil {
+		REOPTIMISE_MARGIN = *file.REOPTIMISE_MARGIN
+	}
+	if file.REOPTIMISE_HOLD_TIME != nil {
+		REOPTIMISE_HOLD_TIME = time.Duration(*file.REOPTIMISE_HOLD_TIME)
+	}
+	if file.OBSTRUCTION_TIMEOUT != nil {
+		OBSTRUCTION_TIMEOUT = time.Duration(*file.OBSTRUCTION_TIMEOUT)
+	}
+	if file.HARDWARE_ERROR_LIMIT != nil {
+		HARDWARE_ERROR_LIMIT = *file.HARDWARE_ERROR_LIMIT
+	}
+	if file.DESTINATION_DISPATCH != nil {
+		DESTINATION_DISPATCH = *file.DESTINATION_DISPATCH
+	}
+	if file.DESTINATION_ENTRY_TIME != nil {
+		DESTINATION_ENTRY_TIME = time.Duration(*file.DESTINATION_ENTRY_TIME)
+	}
+	if file.PARKING_POLICY != nil {
+		PARKING_POLICY = *file.PARKING_POLICY
+	}
+	if file.PARKING_DELAY != nil {
+		PARKING_DELAY = time.Duration(*file.PARKING_DELAY)
+	}
+	if file.LOBBY_FLOOR != nil {
+		LOBBY_FLOOR = *file.LOBBY_FLOOR
+	}
+	if file.DEMAND_HISTORY_FILE != nil {
+		DEMAND_HISTORY_FILE = *file.DEMAND_HISTORY_FILE
+	}
+	if file.SERVED_FLOORS != nil {
+		SERVED_FLOORS = *file.SERVED_FLOORS
+	}
+	if file.LOAD_SENSOR != nil {
+		LOAD_SENSOR = *file.LOAD_SENSOR
+	}
+	if file.FULL_LOAD != nil {
+		FULL_LOAD = *file.FULL_LOAD
+	}
+	if file.API_ADDRESS != nil {
+		API_ADDRESS = *file.API_ADDRESS
+	}
+	if file.API_KILL != nil {
+		API_KILL = *file.API_KILL
+	}
+	if file.LOG_FORMAT != nil {
+		LOG_FORMAT = *file.LOG_FORMAT
+	}
+	if file.LOG_LEVEL != nil {
+		LOG_LEVEL = *file.LOG_LEVEL
+	}
+	if file.LOG_PACKAGE_LEVELS != nil {
+		LOG_PACKAGE_LEVELS = *file.LOG_PACKAGE_LEVELS
+	}
+	if file.JOURNAL_FILE != nil {
+		JOURNAL_FILE = *file.JOURNAL_FILE
+	}
+	if file.MQTT_BROKER != nil {
+		MQTT_BROKER = *file.MQTT_BROKER
+	}
+	if file.MQTT_CLIENT_ID != nil {
+		MQTT_CLIENT_ID = *file.MQTT_CLIENT_ID
+	}
+	if file.MQTT_USERNAME != nil {
+		MQTT_USERNAME = *file.MQTT_USERNAME
+	}
+	if file.MQTT_PASSWORD != nil {
+		MQTT_PASSWORD = *file.MQTT_PASSWORD
+	}
+	if file.MQTT_KEEP_ALIVE != nil {
+		MQTT_KEEP_ALIVE = time.Duration(*file.MQTT_KEEP_ALIVE)
+	}
+	if file.MQTT_STATE_TOPIC != nil {
+		MQTT_STATE_TOPIC = *file.MQTT_STATE_TOPIC
+	}
+	if file.MQTT_HALL_LIGHTS_TOPIC != nil {
+		MQTT_HALL_LIGHTS_TOPIC = *file.MQTT_HALL_LIGHTS_TOPIC
+	}
+	if file.MQTT_ALARM_TOPIC != nil {
+		MQTT_ALARM_TOPIC = *file.MQTT_ALARM_TOPIC
+	}
+	if file.MQTT_HALL_CALL_TOPIC != nil {
+		MQTT_HALL_CALL_TOPIC = *file.MQTT_HALL_CALL_TOPIC
+	}
+	if file.MQTT_SERVICE_TOPIC != nil {
+		MQTT_SERVICE_TOPIC = *file.MQTT_SERVICE_TOPIC
+	}
+	if file.GRPC_ADDRESS != nil {
+		GRPC_ADDRESS = *file.GRPC_ADDRESS
+	}
+	if file.WEBHOOK_URLS != nil {
+		WEBHOOK_URLS = *file.WEBHOOK_URLS
+	}
+	if file.WEBHOOK_EVENTS != nil {
+		WEBHOOK_EVENTS = *file.WEBHOOK_EVENTS
+	}
+	if file.WEBHOOK_OUTBOX_FILE != nil {
+		WEBHOOK_OUTBOX_FILE = *file.WEBHOOK_OUTBOX_FILE
+	}
+	if file.WEBHOOK_TIMEOUT != nil {
+		WEBHOOK_TIMEOUT = time.Duration(*file.WEBHOOK_TIMEOUT)
+	}
+	if file.WEBHOOK_RETRY_MIN != nil {
+		WEBHOOK_RETRY_MIN = time.Duration(*file.WEBHOOK_RETRY_MIN)
+	}
+	if file.WEBHOOK_RETRY_MAX != nil {
+		WEBHOOK_RETRY_MAX = time.Duration(*file.WEBHOOK_RETRY_MAX)
+	}
+	if file.WEBHOOK_HALL_WAIT != nil {
+		WEBHOOK_HALL_WAIT = time.Duration(*file.WEBHOOK_HALL_WAIT)
+	}
+	return validate()
+}
+
+func validate() error {
+	periods := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HEARTBEAT_PERIOD", HEARTBEAT_PERIOD},
+		{"SUSPECT_TIMEOUT", SUSPECT_TIMEOUT},
+		{"INTERFACE_POLL_PERIOD", INTERFACE_POLL_PERIOD},
+		{"REOPTIMISE_PERIOD", REOPTIMISE_PERIOD},
+		{"PARKING_DELAY", PARKING_DELAY},
+	}
+	for _, period := range periods {
+		if period.value <= 0 {
+			return fmt.Errorf("%s must be positive, got %v", period.name, period.value)
+		}
+	}
+	// The failure detector checks four times per SUSPECT_TIMEOUT, and peers must get a heartbeat within it
+	if SUSPECT_TIMEOUT < time.Millisecond*4 || SUSPECT_TIMEOUT <= HEARTBEAT_PERIOD {
+		return fmt.Errorf("SUSPECT_TIMEOUT must be longer than HEARTBEAT_PERIOD (%v) and at least 4ms, got %v", HEARTBEAT_PERIOD, SUSPECT_TIMEOUT)
+	}
+	if err := oneOf("DISCOVERY_TRANSPORT", DISCOVERY_TRANSPORT, "broadcast", "multicast", "static"); err != nil {
+		return err
+	}
+	if err := oneOf("OFFLINE_HALL_POLICY", OFFLINE_HALL_POLICY, "serve", "refuse"); err != nil {
+		return err
+	}
+	if err := oneOf("PARKING_POLICY", PARKING_POLICY, "none", "lobby", "spread", "demand"); err != nil {
+		return err
+	}
+	if LOBBY_FLOOR < 0 || LOBBY_FLOOR >= NUMBER_OF_FLOORS {
+		return fmt.Errorf("LOBBY_FLOOR must be between 0 and %d, got %d", NUMBER_OF_FLOORS-1, LOBBY_FLOOR)
+	}
+	for _, floor := range SERVED_FLOORS {
+		if floor < 0 || floor >= NUMBER_OF_FLOORS {
+			return fmt.Errorf("SERVED_FLOORS must be between 0 and %d, got %d", NUMBER_OF_FLOORS-1, floor)
+		}
+	}
+	if err := oneOf("LOG_FORMAT", LOG_FORMAT, "text", "json"); err != nil {
+		return err
+	}
+	if err := oneOf("LOG_LEVEL", LOG_LEVEL, "debug", "info", "warn", "error"); err != nil {
+		return err
+	}
+	for pkg, level := range LOG_PACKAGE_LEVELS {
+		if err := oneOf("LOG_PACKAGE_LEVELS["+pkg+"]", level, "debug", "info", "warn", "error"); err != nil {
+			return err
+		}
+	}
+	if FULL_LOAD <= 0 || FULL_LOAD > 100 {
+		return fmt.Errorf("FULL_LOAD must be between 1 and 100, got %d", FULL_LOAD)
+	}
+	if MQTT_KEEP_ALIVE < time.Second || MQTT_KEEP_ALIVE > time.Second*65535 {
+		return fmt.Errorf("MQTT_KEEP_ALIVE must be between 1s and 65535s, got %v", MQTT_KEEP_ALIVE)
+	}
+	topics := []struct {
+		name, value  string
+		placeholders []string
+	}{
+		{"MQTT_STATE_TOPIC", MQTT_STATE_TOPIC, []string{"{car}"}},
+		{"MQTT_HALL_LIGHTS_TOPIC", MQTT_HALL_LIGHTS_TOPIC, nil},
+		{"MQTT_ALARM_TOPIC", MQTT_ALARM_TOPIC, []string{"{car}", "{alarm}"}},
+		{"MQTT_HALL_CALL_TOPIC", MQTT_HALL_CALL_TOPIC, nil},
+		{"MQTT_SERVICE_TOPIC", MQTT_SERVICE_TOPIC, []string{"{car}"}},
+	}
+	for _, topic := range topics {
+		if topic.value == "" || strings.ContainsAny(topic.value, "+#") {
+			return fmt.Errorf("%s must be a topic without wildcards, got %q", topic.name, topic.value)
+		}
+		for _, placeholder := range topic.placeholders {
+			if !strings.Contains(topic.value, placeholder) {
+				return fmt.Errorf("%s must contain %s, got %q", topic.name, placeholder, topic.value)
+			}
+		}
+	}
+	for _, endpoint := range WEBHOOK_URLS {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("WEBHOOK_URLS must hold http or https URLs, got %q", endpoint)
+		}
+	}
+	if WEBHOOK_TIMEOUT <= 0 || WEBHOOK_RETRY_MIN <= 0 || WEBHOOK_HALL_WAIT <= 0 {
+		return fmt.Errorf("WEBHOOK_TIMEOUT, WEBHOOK_RETRY_MIN and WEBHOOK_HALL_WAIT must be positive")
+	}
+	if WEBHOOK_RETRY_MAX < WEBHOOK_RETRY_MIN {
+		return fmt.Errorf("WEBHOOK_RETRY_MAX must be at least WEBHOOK_RETRY_MIN, got %v", WEBHOOK_RETRY_MAX)
+	}
+	return nil
+}
+
+func oneOf(name, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %v, got %q", name, allowed, value)
+}
@@ -1,22 +1,48 @@
 package elevator
 
 import (
-	"fmt"
 	"project-group-81/config"
 	"project-group-81/hardware"
+	"project-group-81/journal"
+	"project-group-81/logging"
+	"project-group-81/metrics"
 	"project-group-81/types"
+	"project-group-81/webhook"
 	"time"
 )
 
+var log = logging.For("elevator")
+
 type Elevator struct {
 	LastFloor     types.Floor
 	State         types.ElevatorState
 	LastDirection types.MotorDirection
 	Orders        types.OrderSet
+	Health        Health
+	Floors        []types.Floor // Floors the elevator may stop at, every floor when empty
+	Load          int           // Percent of capacity, always zero without LOAD_SENSOR
+	Full          bool          // Load has reached FULL_LOAD, so hall calls are left to other elevators
+	Obstruction   bool          // Obstruction switch is active, whether or not for long enough to be a fault
+	parking       bool          // Moving to parkingFloor without orders
+	parkingFloor  types.Floor   // Floor the master chose for the idle elevator
 }
 
 func DefaultElevator() Elevator {
-	return Elevator{types.Floor(0), types.Standby, types.MotorHalt, make(map[types.Order]bool)}
+	return Elevator{
+		LastFloor:     types.Floor(0),
+		State:         types.Standby,
+		LastDirection: types.MotorHalt,
+		Orders:        make(map[types.Order]bool)}
+}
+
+// Copy of the elevator that shares no orders with it
+func (e Elevator) copy() Elevator {
+	orders := make(types.OrderSet)
+	for o := range e.Orders {
+		orders.Insert(o)
+	}
+	e.Orders = orders
+	return e
 }
 
 func (e *Elevator) initPhase(hc *hardware.HardwareConn) {
@@ -45,7 +71,15 @@ func RunElevator(hc *hardware.HardwareConn,
 	lightOnChan,
 	lightOffChan <-chan types.Order,
 	stateChan chan<- Elevator,
-	assignedOrderChan <-chan types.Order) {
+	assignedOrderChan,
+	revokedOrderChan <-chan types.Order,
+	connectedChan <-chan bool,
+	parkChan <-chan types.Floor,
+	remoteButtonChan <-chan types.Order,
+	serviceChan <-chan bool,
+	stateRequestChan <-chan chan Elevator,
+	simulatedObstructionChan,
+	simulatedStopChan <-chan bool) {
 
 	floorChan := make(chan types.Floor)
 	buttonChan := make(chan types.Order)
@@ -53,12 +87,34 @@ func RunElevator(hc *hardware.HardwareConn,
 	inactiveTimer := time.NewTimer(config.INACTIVE_TIME)
 	notificationPeriod := 3 * time.Second
 	notificationTimer := time.NewTimer(notificationPeriod)
+	refusedOrderChan := make(chan types.Order)
+	obstructionChan := make(chan bool)
+	stopButtonChan := make(chan bool)
+	loadChan := make(chan int)
+	obstructionTimer := time.NewTimer(config.OBSTRUCTION_TIMEOUT)
+	obstructionTimer.Stop()
+	obstructed := false // Whether the obstruction switch is active, not necessarily for long enough to be a fault
+	var obstructedSince time.Time
+	connected := false                    // Whether the network node shares hall calls with other elevators
+	offlineOrders := make(types.OrderSet) // Hall orders taken while disconnected, uploaded on rejoin
+	var destinationKeypad keypad
+	lit := make(types.OrderSet) // Button lights turned on, so the journal only records lights going out
 
 	go pollFloorSensor(hc, floorChan)
 	go pollButtons(hc, buttonChan)
-	go pollObstruction(hc, doorTimer)
+	go pollObstruction(hc, doorTimer, simulatedObstructionChan, obstructionChan)
+	go pollStopButton(hc, simulatedStopChan, stopButtonChan)
+	if config.LOAD_SENSOR {
+		go pollLoad(hc, loadChan)
+	}
+	go func() {
+		for order := range remoteButtonChan { // Presses from the status API are handled like the real buttons
+			buttonChan <- order
+		}
+	}()
 
 	e := DefaultElevator()
+	e.Floors = config.SERVED_FLOORS
 	e.initPhase(hc)
 
 	for {
@@ -68,13 +124,24 @@ func RunElevator(hc *hardware.HardwareConn,
 				inactiveTimer.Reset(config.INACTIVE_TIME)
 				hc.WriteFloorIndicator(floor)
 				e.LastFloor = floor
-				if e.shouldOpen() {
+				e.Health.MotorFault = false
+				if e.Orders.IsEmpty() && e.parking && floor != e.parkingFloor {
+					// Still on the way to the parking floor
+				} else if e.Orders.IsEmpty() { // Parked, or every order was moved to other elevators while moving
+					hc.WriteMotorDirection(types.MotorHalt)
+					e.State = types.Standby
+					e.parking = false
+				} else if e.shouldOpen() {
 					e.open(hc, doorTimer, inactiveTimer)
 					inactiveTimer.Reset(config.INACTIVE_TIME)
 				} else if e.shouldTurn() {
 					e.turn()
 					if e.shouldOpen() {
 						e.open(hc, doorTimer, inactiveTimer)
+					} else {
+						// Every order ahead was revoked, or an order behind cancelled parking, so the motor
+						// must be turned around as well
+						e.continueMoving(hc)
 					}
 				}
 				go func() {
@@ -83,34 +150,68 @@ func RunElevator(hc *hardware.HardwareConn,
 				notificationTimer.Reset(notificationPeriod)
 			}
 		case order := <-buttonChan:
-			if order.C == types.Car {
+			journal.Record(journal.Pressed, order)
+			if (order.C == types.HallUp || order.C == types.HallDown) && config.DESTINATION_DISPATCH {
+				var entered bool
+				if order, entered = destinationKeypad.press(order, time.Now()); !entered {
+					break
+				}
+			}
+			if order.C == types.Car && !e.ServesFloor(order.F) {
+				refuse(hc, order, refusedOrderChan)
+			} else if order.C == types.Car {
 				if !e.Orders.Contains(order) {
-					e.Orders.Insert(order)
-					hc.WriteOrderButtonLight(order, true)
-					if e.State == types.Standby {
-						if e.LastFloor == order.F {
-							switch order.C {
-							case types.HallDown:
-								e.LastDirection = types.MotorDown
-							case types.HallUp:
-								e.LastDirection = types.MotorUp
-							}
-							e.open(hc, doorTimer, inactiveTimer)
-						} else {
-							e.startMoving(hc, inactiveTimer)
-							e.State = types.Moving
-						}
-					}
+					metrics.OrdersCreated.Inc(order.C.String())
+					e.takeOrder(order, hc, doorTimer, inactiveTimer)
 					go func() {
 						stateChan <- e
 					}()
 					notificationTimer.Reset(notificationPeriod)
 				}
-			} else {
+			} else if connected {
+				journal.Record(journal.Sent, order)
 				go func(order types.Order) {
 					newOrderChan <- order
 				}(order)
+			} else if e.Orders.Contains(order) {
+				// Already being served
+			} else if config.OFFLINE_HALL_POLICY == "serve" && e.ServesOrder(order) {
+				log.Info("Serving hall order locally while disconnected", "order", order)
+				offlineOrders.Insert(order)
+				e.takeOrder(order, hc, doorTimer, inactiveTimer)
+				go func() {
+					stateChan <- e
+				}()
+				notificationTimer.Reset(notificationPeriod)
+			} else {
+				refuse(hc, order, refusedOrderChan)
+			}
+		case order := <-refusedOrderChan:
+			if !e.Orders.Contains(order) {
+				hc.WriteOrderButtonLight(order, false)
+			}
+		case connected = <-connectedChan:
+			if !connected {
+				log.Warn("Network disconnected, handling hall calls locally", "policy", config.OFFLINE_HALL_POLICY)
+				break
+			}
+			// The master assigns them again, so they are dropped here and the light stays on. Keeping
+			// them would leave two cars serving a call the master gives to another elevator.
+			for order := range offlineOrders {
+				if e.Orders.Contains(order) {
+					log.Info("Handing order taken while disconnected to the master", "order", order)
+					journal.Record(journal.Sent, order)
+					e.dropOrder(order, hc)
+					go func(order types.Order) {
+						newOrderChan <- order
+					}(order)
+				}
+				offlineOrders.Remove(order)
 			}
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
 		case <-doorTimer.C:
 			if e.State == types.Carring {
 				inactiveTimer.Reset(config.INACTIVE_TIME)
@@ -138,45 +239,148 @@ func RunElevator(hc *hardware.HardwareConn,
 				notificationTimer.Reset(notificationPeriod)
 			}
 		case <-notificationTimer.C:
+			failing := hc.Failing()
+			if failing && !e.Health.HardwareFault {
+				webhook.Notify(webhook.HardwareErrors, "Requests to the hardware keep failing", "errors", config.HARDWARE_ERROR_LIMIT)
+			}
+			e.Health.HardwareFault = failing
 			go func() {
 				stateChan <- e
 			}()
 			notificationTimer.Reset(notificationPeriod)
-		case order := <-assignedOrderChan:
-			e.Orders.Insert(order)
-			hc.WriteOrderButtonLight(order, true)
-			if e.State == types.Standby {
-				if e.LastFloor == order.F {
-					switch order.C {
-					case types.HallDown:
-						e.LastDirection = types.MotorDown
-					case types.HallUp:
-						e.LastDirection = types.MotorUp
-					}
-					e.open(hc, doorTimer, inactiveTimer)
-					inactiveTimer.Reset(config.INACTIVE_TIME)
+		case obstructed = <-obstructionChan:
+			e.Obstruction = obstructed
+			if obstructed {
+				obstructedSince = time.Now()
+				obstructionTimer.Reset(config.OBSTRUCTION_TIMEOUT)
+			} else {
+				metrics.ObstructionTime.Add(time.Since(obstructedSince).Seconds())
+				obstructionTimer.Stop()
+				if e.Health.Obstructed {
+					log.Info("Obstruction cleared", "floor", e.LastFloor)
+					e.Health.Obstructed = false
+					go func() {
+						stateChan <- e
+					}()
+					notificationTimer.Reset(notificationPeriod)
+				}
+			}
+		case <-obstructionTimer.C:
+			if !obstructed {
+				break
+			} else if e.State != types.Carring {
+				obstructionTimer.Reset(config.OBSTRUCTION_TIMEOUT) // Only a fault while it keeps the door open
+				break
+			}
+			log.Warn("Door obstructed for too long", "timeout", config.OBSTRUCTION_TIMEOUT, "floor", e.LastFloor)
+			e.Health.Obstructed = true
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
+		case e.Health.StopPressed = <-stopButtonChan:
+			hc.WriteStopButtonLight(e.Health.StopPressed)
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
+		case e.Load = <-loadChan:
+			if full := e.Load >= config.FULL_LOAD; full != e.Full {
+				if full {
+					log.Info("Car is full, bypassing hall calls", "load", e.Load)
 				} else {
-					e.startMoving(hc, inactiveTimer)
-					e.State = types.Moving
+					log.Info("Car is no longer full", "load", e.Load)
 				}
+				e.Full = full
 			}
 			go func() {
 				stateChan <- e
 			}()
 			notificationTimer.Reset(notificationPeriod)
+		case inService := <-serviceChan:
+			if inService == !e.Health.OutOfService {
+				break
+			}
+			if inService {
+				log.Info("Elevator put back in service")
+			} else {
+				log.Info("Elevator taken out of service, hall calls are left to other elevators")
+			}
+			e.Health.OutOfService = !inService
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
+		case reply := <-stateRequestChan:
+			reply <- e.copy()
+		case order := <-assignedOrderChan:
+			e.takeOrder(order, hc, doorTimer, inactiveTimer)
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
+		case order := <-revokedOrderChan:
+			// Reassigned to another elevator. The light stays on until that elevator has served it.
+			e.dropOrder(order, hc)
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
+		case floor := <-parkChan:
+			if e.State != types.Standby || !e.Orders.IsEmpty() || floor == e.LastFloor {
+				break
+			}
+			log.Info("Parking", "floor", floor)
+			e.parking = true
+			e.parkingFloor = floor
+			if floor > e.LastFloor {
+				e.LastDirection = types.MotorUp
+			} else {
+				e.LastDirection = types.MotorDown
+			}
+			e.continueMoving(hc)
+			e.State = types.Moving
+			inactiveTimer.Reset(config.INACTIVE_TIME)
+			go func() {
+				stateChan <- e
+			}()
+			notificationTimer.Reset(notificationPeriod)
 		case order := <-lightOffChan:
 			hc.WriteOrderButtonLight(order, false)
+			if lit.Contains(order) {
+				lit.Remove(order)
+				journal.Record(journal.LightOff, order)
+			}
 		case order := <-lightOnChan:
 			hc.WriteOrderButtonLight(order, true)
+			lit.Insert(order)
 		case <-inactiveTimer.C:
-			if e.State != types.Standby {
-				fmt.Printf("Elevator inactive; stopping single elevator.\n")
-				return
+			if e.State == types.Moving {
+				// Keep the cab orders and try again, the motor may get its power back
+				log.Error("No floor reached, reporting motor fault", "timeout", config.INACTIVE_TIME, "floor", e.LastFloor)
+				if !e.Health.MotorFault {
+					webhook.Notify(webhook.ElevatorInactive, "No floor reached while moving", "floor", e.LastFloor, "timeout", config.INACTIVE_TIME.String())
+				}
+				e.Health.MotorFault = true
+				e.continueMoving(hc)
+				inactiveTimer.Reset(config.INACTIVE_TIME)
+				go func() {
+					stateChan <- e
+				}()
+				notificationTimer.Reset(notificationPeriod)
 			}
 		}
 	}
 }
 
+// Flashes the light so the passenger sees the call was not accepted
+func refuse(hc *hardware.HardwareConn, order types.Order, refusedOrderChan chan<- types.Order) {
+	hc.WriteOrderButtonLight(order, true)
+	time.AfterFunc(config.REFUSED_CALL_FLASH_TIME, func() {
+		refusedOrderChan <- order
+	})
+}
+
 func pollFloorSensor(hc *hardware.HardwareConn, c chan<- types.Floor) {
 	for {
 		if inFloor, floor := hc.ReadFloorSensor(); inFloor {
@@ -185,7 +389,9 @@ func pollFloorSensor(hc *hardware.HardwareConn, c chan<- types.Floor) {
 	}
 }
 
+// Reports each press once, however long the button is held
 func pollButtons(hc *hardware.HardwareConn, c chan<- types.Order) {
+	held := make(map[types.Order]bool)
 	for {
 		for i := 0; i < 3; i++ {
 			call := types.Call(i)
@@ -193,19 +399,60 @@ func pollButtons(hc *hardware.HardwareConn, c chan<- types.Order) {
 				floor := types.Floor(j)
 				order := types.Order{C: call, F: floor}
 				pressed := hc.ReadOrderButton(order)
-				if pressed {
+				if pressed && !held[order] {
 					c <- order
 				}
+				held[order] = pressed
 			}
 		}
 	}
 }
 
-func pollObstruction(hc *hardware.HardwareConn, doorTimer *time.Timer) {
+// The switch is active if either the real one or the simulated one from the status API is
+func pollObstruction(hc *hardware.HardwareConn, doorTimer *time.Timer, simulatedChan <-chan bool, c chan<- bool) {
+	previous := false
+	simulated := false
 	for {
-		if hc.ReadObstructionSwitch() {
+		select {
+		case simulated = <-simulatedChan:
+		default:
+		}
+		obstructed := hc.ReadObstructionSwitch() || simulated
+		if obstructed {
 			doorTimer.Reset(config.DOOR_OPEN_TIME)
 		}
+		if obstructed != previous {
+			c <- obstructed
+			previous = obstructed
+		}
+	}
+}
+
+// The button is pressed if either the real one or the simulated one from the status API is
+func pollStopButton(hc *hardware.HardwareConn, simulatedChan <-chan bool, c chan<- bool) {
+	previous := false
+	simulated := false
+	for {
+		select {
+		case simulated = <-simulatedChan:
+		default:
+		}
+		pressed := hc.ReadStopButton() || simulated
+		if pressed != previous {
+			c <- pressed
+			previous = pressed
+		}
+	}
+}
+
+func pollLoad(hc *hardware.HardwareConn, c chan<- int) {
+	previous := 0
+	for {
+		load := hc.ReadLoad()
+		if load != previous {
+			c <- load
+			previous = load
+		}
 	}
 }
 
@@ -213,6 +460,8 @@ func pollObstruction(hc *hardware.HardwareConn, doorTimer *time.Timer) {
 func (e *Elevator) shouldOpen() bool {
 	if e.Orders.Contains(types.Order{C: types.Car, F: types.Floor(e.LastFloor)}) {
 		return true
+	} else if e.bypassesHallCalls() {
+		return false
 	} else {
 		switch e.LastDirection {
 		case types.MotorUp:
@@ -224,7 +473,7 @@ func (e *Elevator) shouldOpen() bool {
 				return true
 			}
 		}
-		return false
+		return len(e.destinationOrders()) > 0
 	}
 }
 
@@ -257,48 +506,139 @@ func (e *Elevator) turn() {
 	}
 }
 
+// Adds an order and gets the elevator going if it was standing still
+func (e *Elevator) takeOrder(order types.Order, hc *hardware.HardwareConn, doorTimer *time.Timer, inactiveTimer *time.Timer) {
+	e.Orders.Insert(order)
+	e.parking = false // A real order cancels parking straight away
+	hc.WriteOrderButtonLight(order, true)
+	if order.C == types.Destination {
+		// Shows waiting passengers which car to board, and lets them check their destination inside
+		hc.WriteOrderButtonLight(types.Order{C: types.Car, F: order.D}, true)
+	}
+	if e.State == types.Standby {
+		if e.LastFloor == order.F {
+			if direction := order.Direction(); direction != types.MotorHalt {
+				e.LastDirection = direction
+			}
+			e.open(hc, doorTimer, inactiveTimer)
+		} else {
+			e.startMoving(hc, inactiveTimer)
+			e.State = types.Moving
+		}
+	}
+}
+
+// Gives up an order that another elevator takes over. The hall light is left to the network, but the
+// destination shown in the car goes out unless the car still stops there.
+func (e *Elevator) dropOrder(order types.Order, hc *hardware.HardwareConn) {
+	e.Orders.Remove(order)
+	if order.C != types.Destination {
+		return
+	}
+	for o := range e.Orders {
+		if (o.C == types.Car && o.F == order.D) || (o.C == types.Destination && o.D == order.D) {
+			return
+		}
+	}
+	hc.WriteOrderButtonLight(types.Order{C: types.Car, F: order.D}, false)
+}
+
 func (e *Elevator) open(hc *hardware.HardwareConn, doorTimer *time.Timer, inactiveTimer *time.Timer) {
 	hc.WriteMotorDirection(types.MotorHalt)
 	hc.WriteDoorOpenLight(true)
 	e.State = types.Carring
+	metrics.DoorCycles.Inc()
 	doorTimer.Reset(config.DOOR_OPEN_TIME)
 	inactiveTimer.Reset(config.INACTIVE_TIME)
 }
 
 func (e *Elevator) startMoving(hc *hardware.HardwareConn, inactiveTimer *time.Timer) {
 	inactiveTimer.Reset(config.INACTIVE_TIME)
+	if direction := e.directionToNearestOrder(); direction != types.MotorHalt {
+		hc.WriteMotorDirection(direction)
+		e.LastDirection = direction
+	}
+}
+
+// Direction of the closest order not on the current floor. Ties go up.
+func (e *Elevator) directionToNearestOrder() types.MotorDirection {
+	direction := types.MotorDirection(types.MotorHalt)
+	nearest := config.NUMBER_OF_FLOORS
 	for o := range e.Orders {
-		if int(o.F) > int(e.LastFloor) {
-			hc.WriteMotorDirection(types.MotorUp)
-			e.LastDirection = types.MotorUp
-			break
-		} else if int(o.F) < int(e.LastFloor) {
-			hc.WriteMotorDirection(types.MotorDown)
-			e.LastDirection = types.MotorDown
-			break
+		if int(o.F) > int(e.LastFloor) && o.F-e.LastFloor <= nearest {
+			nearest = o.F - e.LastFloor
+			direction = types.MotorUp
+		} else if int(o.F) < int(e.LastFloor) && e.LastFloor-o.F < nearest {
+			nearest = e.LastFloor - o.F
+			direction = types.MotorDown
 		}
 	}
+	return direction
 }
 
-func (e *Elevator) removeLastFloorOrders(hc *hardware.HardwareConn, finishedOrderChan chan<- types.Order) {
-	o := types.Order{C: types.Car, F: e.LastFloor}
-	e.Orders.Remove(o)
-	hc.WriteOrderButtonLight(o, false)
+// Orders that are served when the door closes in the current floor
+func (e *Elevator) servedOrders() []types.Order {
+	served := []types.Order{{C: types.Car, F: e.LastFloor}}
+	if e.bypassesHallCalls() {
+		return served
+	}
 	switch e.LastDirection {
 	case types.MotorDown:
-		o := types.Order{C: types.HallDown, F: e.LastFloor}
-		e.Orders.Remove(o)
-		hc.WriteOrderButtonLight(o, false)
-		go func(o types.Order) {
-			finishedOrderChan <- o
-		}(o)
+		served = append(served, types.Order{C: types.HallDown, F: e.LastFloor})
 	case types.MotorUp:
-		o := types.Order{C: types.HallUp, F: e.LastFloor}
+		served = append(served, types.Order{C: types.HallUp, F: e.LastFloor})
+	}
+	return append(served, e.destinationOrders()...)
+}
+
+// A full car only stops for its passengers. Without cab orders nobody would get off, so it serves hall calls
+// rather than driving past them forever.
+func (e *Elevator) bypassesHallCalls() bool {
+	if !e.Full {
+		return false
+	}
+	for o := range e.Orders {
+		if o.C == types.Car {
+			return true
+		}
+	}
+	return false
+}
+
+// Destination orders in the current floor going in the current direction
+func (e *Elevator) destinationOrders() []types.Order {
+	var orders []types.Order
+	for o := range e.Orders {
+		if o.C == types.Destination && o.F == e.LastFloor && o.Direction() == e.LastDirection {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+func (e *Elevator) removeLastFloorOrders(hc *hardware.HardwareConn, finishedOrderChan chan<- types.Order) {
+	for _, o := range e.servedOrders() {
+		if e.Orders.Contains(o) {
+			journal.Record(journal.Served, o)
+			if o.C == types.Car {
+				metrics.OrdersFinished.Inc(o.C.String())
+			}
+		}
 		e.Orders.Remove(o)
 		hc.WriteOrderButtonLight(o, false)
-		go func(o types.Order) {
-			finishedOrderChan <- o
-		}(o)
+		if o.C != types.Car {
+			go func(o types.Order) {
+				finishedOrderChan <- o
+			}(o)
+		}
+		if o.C == types.Destination { // The passengers have boarded, so their destination becomes a cab order
+			destination := types.Order{C: types.Car, F: o.D}
+			if !e.Orders.Contains(destination) {
+				metrics.OrdersCreated.Inc(destination.C.String())
+			}
+			e.Orders.Insert(destination)
+			hc.WriteOrderButtonLight(destination, true)
+		}
 	}
 }
 
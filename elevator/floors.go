@@ -0,0 +1,24 @@
+package elevator
+
+import "project-group-81/types"
+
+// Whether the elevator may stop at the floor
+func (e Elevator) ServesFloor(floor types.Floor) bool {
+	if len(e.Floors) == 0 {
+		return true
+	}
+	for _, f := range e.Floors {
+		if f == floor {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the elevator may stop at every floor of the order
+func (e Elevator) ServesOrder(order types.Order) bool {
+	if order.C == types.Destination && !e.ServesFloor(order.D) {
+		return false
+	}
+	return e.ServesFloor(order.F)
+}
@@ -0,0 +1,49 @@
+package elevator
+
+// Conditions that keep an elevator from serving hall orders. Published with the elevator state.
+type Health struct {
+	MotorFault    bool // No floor reached within INACTIVE_TIME while moving
+	Obstructed    bool // Door held open by the obstruction switch for longer than OBSTRUCTION_TIMEOUT
+	StopPressed   bool // Stop switch is active
+	HardwareFault bool // Repeated errors talking to the hardware
+	OutOfService  bool // Taken out of hall service by an operator
+}
+
+func (h Health) Healthy() bool {
+	return !h.MotorFault && !h.Obstructed && !h.StopPressed && !h.HardwareFault && !h.OutOfService
+}
+
+// Descriptions of the active conditions, empty when healthy
+func (h Health) Faults() []string {
+	var faults []string
+	if h.MotorFault {
+		faults = append(faults, "motor fault")
+	}
+	if h.Obstructed {
+		faults = append(faults, "obstructed")
+	}
+	if h.StopPressed {
+		faults = append(faults, "stop pressed")
+	}
+	if h.HardwareFault {
+		faults = append(faults, "hardware fault")
+	}
+	if h.OutOfService {
+		faults = append(faults, "out of service")
+	}
+	return faults
+}
+
+// Whether the elevator should be given hall orders
+func (e Elevator) AcceptsHallOrders() bool {
+	return e.Health.Healthy() && !e.Full
+}
+
+// Why the elevator is not given hall orders, empty when it is
+func (e Elevator) HallOrderBlockers() []string {
+	blockers := e.Health.Faults()
+	if e.Full {
+		blockers = append(blockers, "full")
+	}
+	return blockers
+}
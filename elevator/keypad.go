@@ -0,0 +1,29 @@
+package elevator
+
+import (
+	"project-group-81/config"
+	"project-group-81/types"
+	"time"
+)
+
+// Destination keypad emulated with the hall buttons, since the hardware has none. Passengers press a
+// hall button on their own floor and then one on their destination floor within DESTINATION_ENTRY_TIME.
+type keypad struct {
+	origin  types.Floor
+	started time.Time // Zero when no entry is in progress
+}
+
+// Returns the destination order once a press completes an entry
+func (k *keypad) press(button types.Order, now time.Time) (types.Order, bool) {
+	inProgress := !k.started.IsZero() && now.Sub(k.started) <= config.DESTINATION_ENTRY_TIME
+	if inProgress && button.F != k.origin {
+		k.started = time.Time{}
+		return types.Order{C: types.Destination, F: k.origin, D: button.F}, true
+	}
+	if !inProgress || button.F != k.origin {
+		log.Info("Destination entry started, press a hall button on the destination floor", "floor", button.F)
+	}
+	k.origin = button.F
+	k.started = now
+	return types.Order{}, false
+}
@@ -0,0 +1,162 @@
+package elevator
+
+import (
+	"project-group-81/config"
+	"project-group-81/types"
+	"time"
+)
+
+// Expected times from now, found by simulating the elevator
+type ServiceTimes struct {
+	Wait       time.Duration // Until the door opens for the simulated order
+	Completion time.Duration // Until every order, including the simulated one, is served
+}
+
+// Upper bound on simulated floor arrivals and door cycles, reached only if an order can never be served
+const SIMULATION_STEP_LIMIT = 8 * config.NUMBER_OF_FLOORS
+
+// Simulates the elevator with the order added, following the same rules as RunElevator, until all
+// orders are served. Time spent in the current state is assumed to be half of its duration.
+func (e Elevator) TimeToServe(order types.Order) ServiceTimes {
+	sim := e.copy()
+
+	never := time.Duration(SIMULATION_STEP_LIMIT) * (config.FLOOR_TRAVEL_TIME + config.DOOR_OPEN_TIME)
+	times := ServiceTimes{never, never}
+	served := false
+	var t time.Duration
+
+	switch sim.State {
+	case types.Standby:
+		sim.Take(order)
+		if sim.State == types.Carring {
+			t += config.DOOR_OPEN_TIME
+		} else {
+			t += config.FLOOR_TRAVEL_TIME
+		}
+	case types.Moving:
+		sim.Take(order)
+		t += config.FLOOR_TRAVEL_TIME / 2
+	case types.Carring:
+		sim.Take(order)
+		t += config.DOOR_OPEN_TIME / 2
+	}
+	if sim.State == types.Carring && sim.serves(order) {
+		times.Wait = 0
+		served = true
+	}
+
+	for step := 0; step < SIMULATION_STEP_LIMIT; step++ {
+		switch sim.State {
+		case types.Moving:
+			sim.Arrive()
+		case types.Carring:
+			sim.CloseDoor()
+			if sim.State == types.Standby {
+				if served {
+					times.Completion = t
+				}
+				return times
+			}
+		}
+		if sim.State == types.Carring {
+			if !served && sim.serves(order) {
+				times.Wait = t
+				served = true
+			}
+			t += config.DOOR_OPEN_TIME
+		} else {
+			t += config.FLOOR_TRAVEL_TIME
+		}
+	}
+	return times
+}
+
+// Adds an order without touching hardware. An elevator in Standby opens the door if the order is
+// in the current floor and starts moving otherwise.
+func (e *Elevator) Take(order types.Order) {
+	e.Orders.Insert(order)
+	if e.State != types.Standby {
+		return
+	}
+	if e.LastFloor == order.F {
+		if direction := order.Direction(); direction != types.MotorHalt {
+			e.LastDirection = direction
+		}
+		e.State = types.Carring
+	} else {
+		e.LastDirection = e.directionToNearestOrder()
+		e.State = types.Moving
+	}
+}
+
+// Moves a Moving elevator one floor and decides whether to stop there, as on a floor sensor event
+func (e *Elevator) Arrive() {
+	switch e.LastDirection {
+	case types.MotorUp:
+		e.LastFloor++
+	case types.MotorDown:
+		e.LastFloor--
+	}
+	if e.Orders.IsEmpty() {
+		e.State = types.Standby
+	} else if e.shouldOpen() {
+		e.State = types.Carring
+	} else if e.shouldTurn() {
+		e.turn()
+		if e.shouldOpen() {
+			e.State = types.Carring
+		}
+	}
+}
+
+// Closes the door of a Carring elevator, as on a door timeout, and returns the orders it served.
+// Served destination orders become cab orders. The elevator is left Carring if the door reopens
+// to announce a change of direction.
+func (e *Elevator) CloseDoor() []types.Order {
+	served := e.servedOrders()
+	for _, o := range served {
+		e.Orders.Remove(o)
+		if o.C == types.Destination {
+			e.Orders.Insert(types.Order{C: types.Car, F: o.D})
+		}
+	}
+	if e.Orders.IsEmpty() {
+		e.State = types.Standby
+	} else if e.shouldTurn() {
+		e.turn()
+		if !e.shouldOpen() {
+			e.State = types.Moving
+		}
+	} else {
+		e.State = types.Moving
+	}
+	return served
+}
+
+// Whether the elevator has stopped, or is about to stop, to serve the order
+func (e Elevator) IsStoppingFor(order types.Order) bool {
+	switch e.State {
+	case types.Carring:
+		return e.serves(order)
+	case types.Moving:
+		next := e
+		switch e.LastDirection {
+		case types.MotorUp:
+			next.LastFloor++
+		case types.MotorDown:
+			next.LastFloor--
+		}
+		return next.LastFloor == order.F && next.shouldOpen() && next.serves(order)
+	}
+	return false
+}
+
+// Whether the order is among those served when the door closes in the current floor
+func (e *Elevator) serves(order types.Order) bool {
+	for _, o := range e.servedOrders() {
+		if o == order {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,16 @@
 module project-group-81
 
-go 1.17
+go 1.21
 
-require github.com/projecthunt/reuseable v0.0.7
+require (
+	github.com/projecthunt/reuseable v0.0.7
+	google.golang.org/grpc v1.65.0
+	google.golang.org/protobuf v1.34.2
+)
 
-require golang.org/x/sys v0.0.0-20211103235746-7861aae1554b // indirect
+require (
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+)
@@ -3,13 +3,20 @@ package hardware
 import (
 	"fmt"
 	"net"
+	"project-group-81/config"
+	"project-group-81/logging"
+	"project-group-81/metrics"
 	"project-group-81/types"
 	"sync"
+	"time"
 )
 
+var log = logging.For("hardware")
+
 type HardwareConn struct {
-	conn  net.Conn
-	mutex sync.Mutex
+	conn   net.Conn
+	mutex  sync.Mutex
+	errors int // Consecutive failed requests
 }
 
 func boolToByte(b bool) byte {
@@ -28,7 +35,27 @@ func DialHardware(rport int) (HardwareConn, error) {
 }
 
 func (hc *HardwareConn) send(message []byte) (int, error) {
-	return hc.conn.Write(message)
+	n, err := hc.conn.Write(message)
+	hc.countError(err)
+	return n, err
+}
+
+func (hc *HardwareConn) countError(err error) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	if err != nil {
+		hc.errors++
+		metrics.HardwareErrors.Inc()
+	} else {
+		hc.errors = 0
+	}
+}
+
+// Whether the last HARDWARE_ERROR_LIMIT requests to the hardware all failed
+func (hc *HardwareConn) Failing() bool {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	return hc.errors >= config.HARDWARE_ERROR_LIMIT
 }
 
 func (hc *HardwareConn) receive() ([]byte, error) {
@@ -39,9 +66,18 @@ func (hc *HardwareConn) receive() ([]byte, error) {
 
 func (hc *HardwareConn) request(message []byte) ([]byte, error) {
 	hc.mutex.Lock()
-	defer hc.mutex.Unlock()
-	hc.send(message)
-	return hc.receive()
+	start := time.Now()
+	_, err := hc.conn.Write(message)
+	var response []byte
+	if err == nil {
+		response, err = hc.receive()
+		metrics.HardwareRequestTime.Observe(time.Since(start).Seconds())
+	} else {
+		response = make([]byte, 4)
+	}
+	hc.mutex.Unlock()
+	hc.countError(err)
+	return response, err
 }
 
 func (hc *HardwareConn) WriteMotorDirection(md types.MotorDirection) {
@@ -50,6 +86,7 @@ func (hc *HardwareConn) WriteMotorDirection(md types.MotorDirection) {
 }
 
 func (hc *HardwareConn) WriteOrderButtonLight(o types.Order, on bool) {
+	o = o.Button()
 	message := []byte{2, byte(o.C), byte(o.F), boolToByte(on)}
 	hc.send(message)
 }
@@ -73,7 +110,7 @@ func (hc *HardwareConn) ReadOrderButton(o types.Order) bool {
 	message := []byte{6, byte(o.C), byte(o.F), 0}
 	response, err := hc.request(message)
 	if err != nil {
-		fmt.Printf("Hardware error in function ReadOrderButton: %v\n", err)
+		log.Warn("Hardware error", "function", "ReadOrderButton", "order", o, "err", err)
 	}
 	active := response[1] == 1
 	return active
@@ -83,7 +120,7 @@ func (hc *HardwareConn) ReadFloorSensor() (bool, types.Floor) {
 	message := []byte{7, 0, 0, 0}
 	response, err := hc.request(message)
 	if err != nil {
-		fmt.Printf("Hardware error in function ReadFloorSensor: %v\n", err)
+		log.Warn("Hardware error", "function", "ReadFloorSensor", "err", err)
 	}
 	active := response[1] == 1
 	floor := types.Floor(response[2])
@@ -94,7 +131,7 @@ func (hc *HardwareConn) ReadStopButton() bool {
 	message := []byte{8, 0, 0, 0}
 	response, err := hc.request(message)
 	if err != nil {
-		fmt.Printf("Hardware error in function ReadStopButton: %v\n", err)
+		log.Warn("Hardware error", "function", "ReadStopButton", "err", err)
 	}
 	active := response[1] == 1
 	return active
@@ -104,8 +141,18 @@ func (hc *HardwareConn) ReadObstructionSwitch() bool {
 	message := []byte{9, 0, 0, 0}
 	response, err := hc.request(message)
 	if err != nil {
-		fmt.Printf("Hardware error in function ReadObstructionSwitch: %v\n", err)
+		log.Warn("Hardware error", "function", "ReadObstructionSwitch", "err", err)
 	}
 	active := response[1] == 1
 	return active
 }
+
+// Load of the car in percent of its capacity. Only simulators and drivers with a load sensor answer this.
+func (hc *HardwareConn) ReadLoad() int {
+	message := []byte{10, 0, 0, 0}
+	response, err := hc.request(message)
+	if err != nil {
+		log.Warn("Hardware error", "function", "ReadLoad", "err", err)
+	}
+	return int(response[1])
+}
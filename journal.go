@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"project-group-81/journal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type journalFlags struct {
+	kind   *string
+	node   *int
+	call   *string
+	floor  *int
+	since  *string
+	until  *string
+	format *string
+}
+
+func newJournalFlags(flags *flag.FlagSet) journalFlags {
+	return journalFlags{
+		kind:   flags.String("kind", "", ""),
+		node:   flags.Int("node", -1, ""),
+		call:   flags.String("call", "", ""),
+		floor:  flags.Int("floor", -1, ""),
+		since:  flags.String("since", "", ""),
+		until:  flags.String("until", "", ""),
+		format: flags.String("format", "table", ""),
+	}
+}
+
+// Prints the events of the journal files that match the flags, oldest first
+func RunJournal(j journalFlags, paths []string) error {
+	if *j.format != "table" && *j.format != "jsonl" {
+		return fmt.Errorf("unknown output format %q, expected table or jsonl", *j.format)
+	}
+	kinds := make(map[journal.Kind]bool)
+	for _, kind := range strings.Split(*j.kind, ",") {
+		if kind == "" {
+			continue
+		}
+		if !validKind(journal.Kind(kind)) {
+			return fmt.Errorf("unknown event kind %q, expected one of %v", kind, journal.Kinds)
+		}
+		kinds[journal.Kind(kind)] = true
+	}
+	since, err := parseTime(*j.since)
+	if err != nil {
+		return err
+	}
+	until, err := parseTime(*j.until)
+	if err != nil {
+		return err
+	}
+	events, err := journal.Read(paths...)
+	if err != nil {
+		return err
+	}
+
+	var matching []journal.Event
+	for _, e := range events {
+		if (len(kinds) == 0 || kinds[e.Kind]) &&
+			(*j.node < 0 || e.Node == *j.node) &&
+			(*j.call == "" || e.Order.C.String() == *j.call) &&
+			(*j.floor < 0 || e.Order.F == *j.floor) &&
+			(since.IsZero() || !e.Time.Before(since)) &&
+			(until.IsZero() || e.Time.Before(until)) {
+			matching = append(matching, e)
+		}
+	}
+
+	if *j.format == "jsonl" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, e := range matching {
+			if err := encoder.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\tNODE\tEVENT\tORDER\tASSIGNEE\n")
+	for _, e := range matching {
+		assignee := ""
+		if e.Previous != nil {
+			assignee = fmt.Sprintf("%d -> ", *e.Previous)
+		}
+		if e.Assignee != nil {
+			assignee += fmt.Sprint(*e.Assignee)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", e.Time.Format("2006-01-02 15:04:05.000"), e.Node, e.Kind, e.Order, assignee)
+	}
+	return w.Flush()
+}
+
+func validKind(kind journal.Kind) bool {
+	for _, k := range journal.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads an RFC 3339 time, or a duration such as 1h meaning that long ago. Empty gives the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("expected an RFC 3339 time or a duration, got %q", s)
+	}
+	return t, nil
+}
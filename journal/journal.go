@@ -0,0 +1,156 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"project-group-81/logging"
+	"project-group-81/types"
+	"sort"
+	"sync"
+	"time"
+)
+
+var log = logging.For("journal")
+
+// Step in the life of an order
+type Kind string
+
+const (
+	Pressed    Kind = "pressed"    // Button pressed on this node's panel
+	Sent       Kind = "sent"       // Handed to the network node, which passes it on to the master
+	Assigned   Kind = "assigned"   // Given to Assignee by the master
+	Confirmed  Kind = "confirmed"  // Assignment confirmed by every active node, so the light is on
+	Reassigned Kind = "reassigned" // Moved from Previous to Assignee by the master
+	Served     Kind = "served"     // Door opened for the order
+	LightOff   Kind = "light_off"  // Button light turned off on this node
+)
+
+// Kinds in the order they happen to an order
+var Kinds = []Kind{Pressed, Sent, Assigned, Confirmed, Reassigned, Served, LightOff}
+
+type Event struct {
+	Time     time.Time
+	Node     int // Node that recorded the event, -1 before it has joined a network
+	Kind     Kind
+	Order    types.Order
+	Assignee *int `json:",omitempty"`
+	Previous *int `json:",omitempty"`
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("%s node %d %s %s", e.Time.Format(time.RFC3339Nano), e.Node, e.Kind, e.Order)
+	if e.Previous != nil {
+		s += fmt.Sprintf(" from node %d", *e.Previous)
+	}
+	if e.Assignee != nil {
+		s += fmt.Sprintf(" to node %d", *e.Assignee)
+	}
+	return s
+}
+
+// Events kept in memory for Recent, whether or not a file is open
+const RECENT_EVENTS = 200
+
+var journal = struct {
+	sync.Mutex
+	file   *os.File
+	node   int
+	recent []Event // The latest events, oldest first
+}{node: -1}
+
+// Appends events to the file from now on, creating it if needed. Several processes may share a file.
+func Open(path string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	journal.Lock()
+	defer journal.Unlock()
+	journal.file = file
+	return nil
+}
+
+// Id of this node in the events recorded from now on
+func SetNode(id int) {
+	journal.Lock()
+	defer journal.Unlock()
+	journal.node = id
+}
+
+func Record(kind Kind, order types.Order) {
+	write(Event{Kind: kind, Order: order})
+}
+
+// Records an assignment, or a reassignment if previous is not negative
+func RecordAssignment(kind Kind, order types.Order, previous, assignee int) {
+	event := Event{Kind: kind, Order: order, Assignee: &assignee}
+	if previous >= 0 {
+		event.Previous = &previous
+	}
+	write(event)
+}
+
+func write(event Event) {
+	journal.Lock()
+	defer journal.Unlock()
+	event.Time = time.Now()
+	event.Node = journal.node
+	journal.recent = append(journal.recent, event)
+	if len(journal.recent) > RECENT_EVENTS {
+		journal.recent = journal.recent[len(journal.recent)-RECENT_EVENTS:]
+	}
+	if journal.file == nil {
+		return
+	}
+	line, err := json.Marshal(event)
+	if err == nil {
+		_, err = journal.file.Write(append(line, '\n')) // One write per line, so lines from several processes do not mix
+	}
+	if err != nil {
+		log.Warn("Failed to write journal event", "file", journal.file.Name(), "kind", event.Kind, "order", event.Order, "err", err)
+	}
+}
+
+// The events this process recorded after the time, oldest first, as far back as RECENT_EVENTS go
+func Recent(after time.Time) []Event {
+	journal.Lock()
+	defer journal.Unlock()
+	events := []Event{}
+	for _, event := range journal.recent {
+		if event.Time.After(after) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Reads the events of every file, oldest first
+func Read(paths ...string) ([]Event, error) {
+	var events []Event
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for line := 1; scanner.Scan(); line++ {
+			var event Event
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s line %d: %v", path, line, err)
+			}
+			events = append(events, event)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Output shared by the loggers of every package. Loggers are created before the configuration is
+// loaded, so they look it up on every record rather than when they are created.
+var output = struct {
+	sync.RWMutex
+	handler slog.Handler
+	writer  io.Writer
+	format  string
+	level   slog.Level            // Level of packages without their own
+	levels  map[string]slog.Level // Levels by package
+	node    []slog.Attr           // node_id, role and epoch, once the network node has set them
+}{
+	handler: newHandler(os.Stdout, "text"),
+	writer:  os.Stdout,
+	format:  "text",
+	level:   slog.LevelInfo,
+}
+
+func newHandler(w io.Writer, format string) slog.Handler {
+	options := &slog.HandlerOptions{Level: slog.LevelDebug} // Levels are checked by packageHandler
+	if format == "json" {
+		return slog.NewJSONHandler(w, options)
+	}
+	return slog.NewTextHandler(w, options)
+}
+
+// Chooses "text" or "json" output, the default level, and levels for single packages such as
+// {"network": "debug"}
+func Configure(format, level string, packageLevels map[string]string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("log format must be text or json, got %q", format)
+	}
+	defaultLevel, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels := make(map[string]slog.Level)
+	for pkg, l := range packageLevels {
+		if levels[pkg], err = ParseLevel(l); err != nil {
+			return fmt.Errorf("package %s: %v", pkg, err)
+		}
+	}
+	output.Lock()
+	defer output.Unlock()
+	output.handler = newHandler(output.writer, format)
+	output.format = format
+	output.level = defaultLevel
+	output.levels = levels
+	return nil
+}
+
+// Writes records to w instead of standard output, for when the terminal is used for something else
+func SetOutput(w io.Writer) {
+	output.Lock()
+	defer output.Unlock()
+	output.writer = w
+	output.handler = newHandler(w, output.format)
+}
+
+// Reads "debug", "info", "warn" or "error"
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return l, fmt.Errorf("log level must be debug, info, warn or error, got %q", level)
+	}
+	return l, nil
+}
+
+// Adds the node's id, role ("master", "slave", "observer" or "searching") and master epoch to every record from now on
+func SetNode(id int, role string, epoch int) {
+	output.Lock()
+	defer output.Unlock()
+	output.node = []slog.Attr{slog.Int("node_id", id), slog.String("role", role), slog.Int("epoch", epoch)}
+}
+
+// Logger for the package, which adds itself to every record as the package field
+func For(pkg string) *slog.Logger {
+	return slog.New(&packageHandler{pkg: pkg})
+}
+
+type packageHandler struct {
+	pkg   string
+	apply []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, replayed on the current output
+}
+
+func (h *packageHandler) Enabled(_ context.Context, level slog.Level) bool {
+	output.RLock()
+	defer output.RUnlock()
+	minimum, found := output.levels[h.pkg]
+	if !found {
+		minimum = output.level
+	}
+	return level >= minimum
+}
+
+func (h *packageHandler) Handle(ctx context.Context, r slog.Record) error {
+	output.RLock()
+	handler := output.handler.WithAttrs(append(append([]slog.Attr{}, output.node...), slog.String("package", h.pkg)))
+	output.RUnlock()
+	for _, apply := range h.apply {
+		handler = apply(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *packageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler {
+		return handler.WithAttrs(attrs)
+	})
+}
+
+func (h *packageHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler {
+		return handler.WithGroup(name)
+	})
+}
+
+func (h *packageHandler) with(apply func(slog.Handler) slog.Handler) slog.Handler {
+	return &packageHandler{h.pkg, append(append([]func(slog.Handler) slog.Handler{}, h.apply...), apply)}
+}
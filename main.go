@@ -1,63 +1,269 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"project-group-81/api"
+	"project-group-81/benchmark"
+	"project-group-81/bridge"
 	"project-group-81/config"
 	"project-group-81/elevator"
 	"project-group-81/hardware"
+	"project-group-81/journal"
+	"project-group-81/logging"
+	"project-group-81/mqtt"
 	"project-group-81/network"
+	"project-group-81/rpc"
 	"project-group-81/types"
+	"project-group-81/webhook"
 	"strconv"
+	"strings"
 	"time"
 )
 
+var log = logging.For("main")
+
+// Flags given on the command line, passed on to elevators spawned by this process
+var forwardedFlags []string
+
 func SpawnSimulator(hwPort int) error {
 	return exec.Command("gnome-terminal", "--", "./SimElevatorServer", "--numfloors", strconv.Itoa(config.NUMBER_OF_FLOORS), "--port", strconv.Itoa(hwPort)).Run()
 }
 
 func SpawnElevator(hwPort int) error {
-	return exec.Command("gnome-terminal", "--", "go", "run", ".", "single", strconv.Itoa(hwPort)).Run()
+	args := append([]string{"--", "go", "run", ".", "single"}, forwardedFlags...)
+	args = append(args, strconv.Itoa(hwPort))
+	return exec.Command("gnome-terminal", args...).Run()
+}
+
+// WEBHOOK_OUTBOX_FILE with the hardware port added to its name, as the elevators of a system share their
+// configuration but each keeps its own outbox
+func outboxFile(hwPort int) string {
+	if config.WEBHOOK_OUTBOX_FILE == "" {
+		return ""
+	}
+	extension := filepath.Ext(config.WEBHOOK_OUTBOX_FILE)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(config.WEBHOOK_OUTBOX_FILE, extension), hwPort, extension)
 }
 
 func Run(hwPort int) {
-	fmt.Print("Connecting to hardware.\n")
+	log.Info("Connecting to hardware", "port", hwPort)
 	hc, err := hardware.DialHardware(hwPort)
 	if err != nil {
-		fmt.Printf("Failed to dial hardware: %v\n", err)
+		log.Error("Failed to dial hardware", "port", hwPort, "err", err)
 		return
 	}
 
+	if config.JOURNAL_FILE != "" {
+		if err := journal.Open(config.JOURNAL_FILE); err != nil {
+			log.Error("Failed to open journal", "file", config.JOURNAL_FILE, "err", err)
+		}
+	}
+	if len(config.WEBHOOK_URLS) > 0 {
+		go webhook.Run(outboxFile(hwPort))
+	}
+
 	newOrderChan := make(chan types.Order)
 	finishedOrderChan := make(chan types.Order)
 	stateChan := make(chan elevator.Elevator)
 	assignedOrderChan := make(chan types.Order)
+	revokedOrderChan := make(chan types.Order)
 	lightOnChan := make(chan types.Order)
 	lightOffChan := make(chan types.Order)
+	connectedChan := make(chan bool)
+	parkChan := make(chan types.Floor)
+	remoteButtonChan := make(chan types.Order)
+	serviceChan := make(chan bool)
+	stateRequestChan := make(chan chan elevator.Elevator)
+	statusRequestChan := make(chan chan network.Status)
+	reassignChan := make(chan bool)
+	simulatedObstructionChan := make(chan bool)
+	simulatedStopChan := make(chan bool)
+	carRequestChan := make(chan network.CarRequest)
 
-	ipAddress := network.GetIPAddress()
+	ipAddress, err := network.GetIPAddress()
+	if err != nil {
+		log.Error("Failed to find IP address", "err", err)
+		return
+	}
 	hwSocket := network.Socket{Address: ipAddress, Port: fmt.Sprint(hwPort)}
 
+	if config.API_ADDRESS != "" {
+		go func() {
+			err := api.Serve(config.API_ADDRESS, stateRequestChan, statusRequestChan, remoteButtonChan, serviceChan, reassignChan, simulatedObstructionChan, simulatedStopChan)
+			log.Error("Status API stopped", "err", err)
+		}()
+	}
+
+	if config.GRPC_ADDRESS != "" {
+		go func() {
+			err := rpc.Serve(config.GRPC_ADDRESS, statusRequestChan, remoteButtonChan, carRequestChan)
+			log.Error("gRPC API stopped", "err", err)
+		}()
+	}
+
+	if config.MQTT_BROKER != "" {
+		clientId := config.MQTT_CLIENT_ID
+		if clientId == "" {
+			clientId = fmt.Sprintf("elevator-%s-%d", ipAddress, hwPort)
+		}
+		options := mqtt.Options{ClientId: clientId, Username: config.MQTT_USERNAME, Password: config.MQTT_PASSWORD, KeepAlive: config.MQTT_KEEP_ALIVE}
+		go bridge.Serve(func() (mqtt.Client, error) {
+			return mqtt.Dial(config.MQTT_BROKER, options)
+		}, statusRequestChan, remoteButtonChan, serviceChan)
+	}
+
 	// Initializing network node
-	go network.InitializeNode(hwSocket, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
-	elevator.RunElevator(&hc, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
-	SpawnElevator(hwPort)
+	go network.InitializeNode(hwSocket, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
+	// Never returns. A stuck elevator reports a motor fault or obstruction and stays in the network
+	// instead of restarting, so the master moves its hall orders and it resumes once it moves again.
+	elevator.RunElevator(&hc, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, remoteButtonChan, serviceChan, stateRequestChan, simulatedObstructionChan, simulatedStopChan)
+}
+
+// Joins the network as an observer without an elevator, serving the status API and the gRPC API on the
+// addresses that are not empty
+func Observe(apiAddress, grpcAddress string) {
+	newOrderChan := make(chan types.Order)
+	statusRequestChan := make(chan chan network.Status)
+	carRequestChan := make(chan network.CarRequest)
+	if apiAddress != "" {
+		go func() {
+			err := api.Serve(apiAddress, nil, statusRequestChan, newOrderChan, nil, nil, nil, nil)
+			log.Error("Status API stopped", "err", err)
+		}()
+	}
+	if grpcAddress != "" {
+		go func() {
+			err := rpc.Serve(grpcAddress, statusRequestChan, newOrderChan, carRequestChan)
+			log.Error("gRPC API stopped", "err", err)
+		}()
+	}
+	network.RunObserver(newOrderChan, statusRequestChan, carRequestChan)
+}
+
+func usage() {
+	fmt.Printf("Usage:\n")
+	fmt.Printf("  %s single [flags] <hardware port>\n", os.Args[0])
+	fmt.Printf("  %s system [flags] <number of elevators> <base hardware port>\n", os.Args[0])
+	fmt.Printf("  %s observe [flags]\n", os.Args[0])
+	fmt.Printf("  %s bench [flags]\n", os.Args[0])
+	fmt.Printf("  %s journal [flags] <journal file>...\n", os.Args[0])
+	fmt.Printf("  %s monitor [flags] [status API address]...\n", os.Args[0])
+	fmt.Printf("Flags:\n")
+	fmt.Printf("  -config <file>       JSON file overriding the settings in the config package\n")
+	fmt.Printf("  -interface <name>    Network interface to use instead of selecting one automatically\n")
+	fmt.Printf("  -api <address>       Serve the HTTP status and control API on the address, e.g. :8080\n")
+	fmt.Printf("  -grpc <address>      Serve the gRPC API on the address, e.g. :50051\n")
+	fmt.Printf("Benchmark flags:\n")
+	fmt.Printf("  -elevators <n>       Number of simulated elevators (default 3)\n")
+	fmt.Printf("  -profile <name>      Traffic profile, one of %v (default up-peak)\n", benchmark.Profiles())
+	fmt.Printf("  -rate <n>            Passengers per minute for the whole building (default 6)\n")
+	fmt.Printf("  -duration <d>        Time passengers keep arriving (default 1h)\n")
+	fmt.Printf("  -seed <n>            Seed of the generated traffic (default 1)\n")
+	fmt.Printf("  -strategies <list>   Comma separated dispatch strategies to compare (default all of %v)\n", network.Strategies())
+	fmt.Printf("  -format <format>     Output as a \"table\" or \"csv\" (default table)\n")
+	fmt.Printf("Journal flags:\n")
+	fmt.Printf("  -kind <list>         Comma separated event kinds to show, of %v (default all)\n", journal.Kinds)
+	fmt.Printf("  -node <id>           Only events recorded by the node\n")
+	fmt.Printf("  -call <call>         Only orders of the call type: HallUp, HallDown, Car or Destination\n")
+	fmt.Printf("  -floor <n>           Only orders on the floor\n")
+	fmt.Printf("  -since <time>        Only events from an RFC 3339 time, or a duration ago such as 1h\n")
+	fmt.Printf("  -until <time>        Only events before an RFC 3339 time, or a duration ago\n")
+	fmt.Printf("  -format <format>     Output as a \"table\" or JSON lines with \"jsonl\" (default table)\n")
+	fmt.Printf("Monitor flags:\n")
+	fmt.Printf("  -refresh <d>         Time between asking the nodes for their state (default 500ms)\n")
+	fmt.Printf("  -events <n>          Number of order events to show (default 12)\n")
+	fmt.Printf("  -observe             Join the network as an observer, so no status API address is needed\n")
 }
 
 func main() {
-	args := os.Args[1:]
-	if args[0] == "single" {
-		hwPort, _ := strconv.Atoi(args[1])
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	flags.Usage = usage
+	configPath := flags.String("config", "", "")
+	interfaceName := flags.String("interface", "", "")
+	apiAddress := flags.String("api", "", "")
+	grpcAddress := flags.String("grpc", "", "")
+	var bench benchmarkFlags
+	var journalQuery journalFlags
+	var monitorOptions monitorFlags
+	if os.Args[1] == "bench" {
+		bench = newBenchmarkFlags(flags)
+	} else if os.Args[1] == "journal" {
+		journalQuery = newJournalFlags(flags)
+	} else if os.Args[1] == "monitor" {
+		monitorOptions = newMonitorFlags(flags)
+	}
+	flags.Parse(os.Args[2:])
+	flags.Visit(func(f *flag.Flag) {
+		forwardedFlags = append(forwardedFlags, "-"+f.Name, f.Value.String())
+	})
+	if *configPath != "" {
+		if err := config.Load(*configPath); err != nil {
+			fmt.Printf("Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := logging.Configure(config.LOG_FORMAT, config.LOG_LEVEL, config.LOG_PACKAGE_LEVELS); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if *interfaceName != "" {
+		config.NETWORK_INTERFACE = *interfaceName
+	}
+	if *apiAddress != "" {
+		config.API_ADDRESS = *apiAddress
+	}
+	if *grpcAddress != "" {
+		config.GRPC_ADDRESS = *grpcAddress
+	}
+	if _, err := network.NewAssigner(config.DISPATCH_STRATEGY); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := webhook.CheckKinds(config.WEBHOOK_EVENTS); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	args := flags.Args()
+	if os.Args[1] == "single" && len(args) == 1 {
+		hwPort, _ := strconv.Atoi(args[0])
 		Run(hwPort)
-	} else if args[0] == "system" {
-		elevators, _ := strconv.Atoi(args[1])
-		base_hwPort, _ := strconv.Atoi(args[2])
-		fmt.Printf("Running with %d simulators.\n", elevators)
+	} else if os.Args[1] == "system" && len(args) == 2 {
+		elevators, _ := strconv.Atoi(args[0])
+		base_hwPort, _ := strconv.Atoi(args[1])
+		log.Info("Running with simulators", "elevators", elevators)
 		for i := 0; i < elevators; i++ {
 			SpawnSimulator(base_hwPort + i)
 			SpawnElevator(base_hwPort + i)
 			time.Sleep(time.Second * 5)
 		}
+	} else if os.Args[1] == "observe" && len(args) == 0 {
+		Observe(config.API_ADDRESS, config.GRPC_ADDRESS)
+	} else if os.Args[1] == "bench" && len(args) == 0 {
+		if err := RunBenchmark(bench); err != nil {
+			fmt.Printf("Benchmark failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else if os.Args[1] == "journal" && len(args) > 0 {
+		if err := RunJournal(journalQuery, args); err != nil {
+			fmt.Printf("Journal query failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else if os.Args[1] == "monitor" && (len(args) > 0 || *monitorOptions.observe) {
+		if err := RunMonitor(monitorOptions, args); err != nil {
+			fmt.Printf("Monitor failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		usage()
+		os.Exit(2)
 	}
 }
@@ -0,0 +1,37 @@
+package metrics
+
+// Orders are counted where they are handled: cab orders by the elevator taking them, other orders
+// by the master while it is master.
+var (
+	OrdersCreated    = NewCounter("elevator_orders_created_total", "Orders created, by call type.", "call")
+	OrdersFinished   = NewCounter("elevator_orders_finished_total", "Orders served, by call type.", "call")
+	OrdersReassigned = NewCounter("elevator_orders_reassigned_total", "Orders moved from one elevator to another by the master, by call type.", "call")
+	HallCallWait     = NewHistogram("elevator_hall_call_wait_seconds", "Time from a hall call reaching the master until it was served, by call type.",
+		[]float64{1, 2, 5, 10, 20, 30, 60, 120, 300}, "call")
+)
+
+// Local elevator
+var (
+	DoorCycles      = NewCounter("elevator_door_cycles_total", "Times the door was opened.")
+	ObstructionTime = NewCounter("elevator_obstruction_seconds_total", "Time the obstruction switch has been active.")
+)
+
+// Network node
+var (
+	MasterElections      = NewCounter("network_master_elections_total", "Times this node took over as master.")
+	MasterId             = NewGauge("network_master_id", "Id of the current master as seen by this node.")
+	ActiveProcesses      = NewGauge("network_active_processes", "Elevator processes the node believes to be active, its own included. Observers are not counted.")
+	MessagesSent         = NewCounter("network_messages_sent_total", "Messages sent to other nodes, by flag.", "flag")
+	MessageBytesSent     = NewCounter("network_message_bytes_sent_total", "Bytes sent to other nodes, including packet framing, by flag.", "flag")
+	MessagesReceived     = NewCounter("network_messages_received_total", "Messages received from other nodes, by flag.", "flag")
+	MessageBytesReceived = NewCounter("network_message_bytes_received_total", "Bytes received from other nodes, including packet framing, by flag.", "flag")
+	ConsistencyRoundTime = NewHistogram("network_consistency_round_seconds", "Time from the master changing the assigned orders until every active node confirmed them.",
+		[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5})
+)
+
+// Hardware connection
+var (
+	HardwareRequestTime = NewHistogram("hardware_request_duration_seconds", "Time to get an answer from the hardware.",
+		[]float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.1})
+	HardwareErrors = NewCounter("hardware_errors_total", "Failed reads and writes to the hardware.")
+)
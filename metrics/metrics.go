@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metrics by name, written in the Prometheus text exposition format by WriteText
+var registry = struct {
+	sync.Mutex
+	families map[string]*family
+}{families: make(map[string]*family)}
+
+// Every series of one metric, one per combination of label values
+type family struct {
+	sync.Mutex
+	name    string
+	help    string
+	kind    string // "counter", "gauge" or "histogram"
+	labels  []string
+	buckets []float64 // Upper bounds of histogram buckets, in increasing order
+	series  map[string]*series
+}
+
+type series struct {
+	labels []string
+	value  float64  // Counter or gauge value, or the sum of observations for histograms
+	counts []uint64 // Observations per histogram bucket, not cumulative
+	count  uint64   // Histogram observations
+}
+
+func register(name, help, kind string, labels []string, buckets []float64) *family {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, found := registry.families[name]; found {
+		panic(fmt.Sprintf("metric %s registered twice", name))
+	}
+	f := &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	if len(labels) == 0 {
+		f.get(nil) // Shown as zero before the first update
+	}
+	registry.families[name] = f
+	return f
+}
+
+// The series with the label values, created on first use. Callers hold the family lock.
+func (f *family) get(labels []string) *series {
+	if len(labels) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s takes labels %v, got %v", f.name, f.labels, labels))
+	}
+	key := strings.Join(labels, "\xff")
+	s, found := f.series[key]
+	if !found {
+		s = &series{labels: labels, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+// A value that only goes up, such as the number of orders served
+type Counter struct{ f *family }
+
+func NewCounter(name, help string, labels ...string) Counter {
+	return Counter{register(name, help, "counter", labels, nil)}
+}
+
+func (c Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c Counter) Add(v float64, labels ...string) {
+	c.f.Lock()
+	defer c.f.Unlock()
+	c.f.get(labels).value += v
+}
+
+// A value that goes up and down, such as the number of active processes
+type Gauge struct{ f *family }
+
+func NewGauge(name, help string, labels ...string) Gauge {
+	return Gauge{register(name, help, "gauge", labels, nil)}
+}
+
+func (g Gauge) Set(v float64, labels ...string) {
+	g.f.Lock()
+	defer g.f.Unlock()
+	g.f.get(labels).value = v
+}
+
+// Counts observations, such as waiting times, in buckets of increasing upper bounds
+type Histogram struct{ f *family }
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	return Histogram{register(name, help, "histogram", labels, buckets)}
+}
+
+func (h Histogram) Observe(v float64, labels ...string) {
+	h.f.Lock()
+	defer h.f.Unlock()
+	s := h.f.get(labels)
+	s.value += v
+	s.count++
+	for i, bound := range h.f.buckets {
+		if v <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+}
+
+// Writes every metric in the Prometheus text exposition format, sorted by name
+func WriteText(w io.Writer) error {
+	registry.Lock()
+	families := make([]*family, 0, len(registry.families))
+	for _, f := range registry.families {
+		families = append(families, f)
+	}
+	registry.Unlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.Lock()
+	defer f.Unlock()
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escape(f.help, false))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelText(s.labels, ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelText(s.labels, formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelText(s.labels, "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelText(s.labels, ""), formatFloat(s.value))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelText(s.labels, ""), s.count)
+	}
+}
+
+// Labels in braces, with the histogram bucket bound as le if given
+func (f *family) labelText(values []string, le string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", name, escape(values[i], true)))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf("le=\"%s\"", le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escape(s string, quotes bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"project-group-81/api"
+	"project-group-81/elevator"
+	"project-group-81/journal"
+	"project-group-81/logging"
+	"project-group-81/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type monitorFlags struct {
+	refresh *time.Duration
+	events  *int
+	observe *bool
+}
+
+func newMonitorFlags(flags *flag.FlagSet) monitorFlags {
+	return monitorFlags{
+		refresh: flags.Duration("refresh", time.Millisecond*500, ""),
+		events:  flags.Int("events", 12, ""),
+		observe: flags.Bool("observe", false, ""),
+	}
+}
+
+const MONITOR_HELP = "up <floor> | down <floor> | car <node> <floor> | obstruct <node> | stop <node> | kill <node> | reassign | quit"
+
+// What the monitor knows about the nodes, refreshed from their status APIs
+type monitor struct {
+	flags     monitorFlags
+	addresses []string                // Status API of every node given on the command line
+	buildings map[string]api.Building // Latest answer of each node, missing if it did not answer
+	events    []journal.Event         // Events of every node, oldest first
+	after     map[string]time.Time    // Time of the latest event fetched from each node
+	input     string                  // Command being typed
+	message   string                  // Outcome of the last command
+}
+
+// Answers of the nodes to one refresh
+type monitorUpdate struct {
+	buildings map[string]api.Building
+	events    map[string][]journal.Event
+}
+
+var monitorClient = http.Client{Timeout: time.Second * 2}
+
+// Draws the building seen by the nodes with the status APIs at the addresses until the user quits,
+// and sends the commands typed by the user to them
+func RunMonitor(m monitorFlags, addresses []string) error {
+	if *m.refresh <= 0 {
+		return fmt.Errorf("refresh must be positive, got %v", *m.refresh)
+	}
+	restore, err := rawTerminal()
+	if err != nil {
+		return fmt.Errorf("monitor needs a terminal: %v", err)
+	}
+	defer restore()
+	defer fmt.Print("\x1b[0m\n")
+	if *m.observe {
+		// The observer gets a status API of its own, so it is shown like any other node
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		address := listener.Addr().String()
+		listener.Close()
+		logging.SetOutput(io.Discard) // Would be drawn over
+		go Observe(address, "")
+		addresses = append([]string{address}, addresses...)
+	}
+
+	mon := &monitor{flags: m, addresses: addresses, buildings: make(map[string]api.Building), after: make(map[string]time.Time)}
+	keyChan := make(chan rune)
+	updateChan := make(chan monitorUpdate)
+	resultChan := make(chan string)
+	interruptChan := make(chan os.Signal, 1)
+	signal.Notify(interruptChan, os.Interrupt)
+	defer signal.Stop(interruptChan)
+	go readKeys(keyChan)
+
+	ticker := time.NewTicker(*m.refresh)
+	defer ticker.Stop()
+	fetching := true
+	go mon.fetch(mon.copyAfter(), updateChan)
+	mon.draw()
+	for {
+		select {
+		case <-ticker.C:
+			if !fetching {
+				fetching = true
+				go mon.fetch(mon.copyAfter(), updateChan)
+			}
+		case update := <-updateChan:
+			fetching = false
+			mon.apply(update)
+		case message := <-resultChan:
+			mon.message = message
+		case <-interruptChan:
+			return nil
+		case key := <-keyChan:
+			switch key {
+			case '\r', '\n':
+				command := strings.Fields(mon.input)
+				mon.input = ""
+				if len(command) == 0 {
+					break
+				}
+				if command[0] == "quit" || command[0] == "q" {
+					return nil
+				}
+				mon.message = "..."
+				go func(addresses map[int]string, hallAddress, elevatorAddress string) {
+					resultChan <- runMonitorCommand(command, addresses, hallAddress, elevatorAddress)
+				}(mon.nodeAddresses(), mon.hallAddress(), mon.elevatorAddress())
+			case 127, '\b':
+				if len(mon.input) > 0 {
+					mon.input = mon.input[:len(mon.input)-1]
+				}
+			case 4: // Ctrl-D
+				return nil
+			default:
+				if key >= ' ' && key < 127 {
+					mon.input += string(key)
+				}
+			}
+		}
+		mon.draw()
+	}
+}
+
+// Makes the terminal pass on every key at once without echoing it, and returns how to undo it
+func rawTerminal() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(state)
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// Sends the typed keys, leaving out escape sequences such as those of the arrow keys
+func readKeys(c chan<- rune) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		key, _, err := reader.ReadRune()
+		if err != nil {
+			c <- 4
+			return
+		}
+		if key == 0x1b {
+			if next, _, _ := reader.ReadRune(); next == '[' || next == 'O' {
+				reader.ReadRune()
+			}
+			continue
+		}
+		c <- key
+	}
+}
+
+func (mon *monitor) copyAfter() map[string]time.Time {
+	after := make(map[string]time.Time)
+	for address, t := range mon.after {
+		after[address] = t
+	}
+	return after
+}
+
+func (mon *monitor) fetch(after map[string]time.Time, c chan<- monitorUpdate) {
+	update := monitorUpdate{buildings: make(map[string]api.Building), events: make(map[string][]journal.Event)}
+	for _, address := range mon.addresses {
+		var b api.Building
+		if getJSON(address, "/building", nil, &b) != nil {
+			continue
+		}
+		update.buildings[address] = b
+		var events []journal.Event
+		query := url.Values{}
+		if !after[address].IsZero() {
+			query.Set("after", after[address].Format(time.RFC3339Nano))
+		}
+		if getJSON(address, "/journal", query, &events) == nil {
+			update.events[address] = events
+		}
+	}
+	c <- update
+}
+
+func (mon *monitor) apply(update monitorUpdate) {
+	mon.buildings = update.buildings
+	for address, events := range update.events {
+		for _, e := range events {
+			if e.Time.After(mon.after[address]) {
+				mon.after[address] = e.Time
+			}
+		}
+		mon.events = append(mon.events, events...)
+	}
+	sort.SliceStable(mon.events, func(i, j int) bool {
+		return mon.events[i].Time.Before(mon.events[j].Time)
+	})
+	if len(mon.events) > *mon.flags.events {
+		mon.events = mon.events[len(mon.events)-*mon.flags.events:]
+	}
+}
+
+// The building as seen by the master if it answers, otherwise by the first node that knows a master
+func (mon *monitor) view() (string, api.Building, bool) {
+	found := false
+	var address string
+	var view api.Building
+	for _, a := range mon.addresses {
+		b, answered := mon.buildings[a]
+		if !answered || b.Searching {
+			continue
+		}
+		if b.Role == "master" {
+			return a, b, true
+		}
+		if !found {
+			address, view, found = a, b, true
+		}
+	}
+	return address, view, found
+}
+
+// Status API address of each node by id, for the nodes that answered
+func (mon *monitor) nodeAddresses() map[int]string {
+	addresses := make(map[int]string)
+	for address, b := range mon.buildings {
+		if !b.Searching {
+			addresses[b.NodeId] = address
+		}
+	}
+	return addresses
+}
+
+// Node to send hall calls to
+func (mon *monitor) hallAddress() string {
+	if address, _, found := mon.view(); found {
+		return address
+	}
+	for _, address := range mon.addresses {
+		if _, answered := mon.buildings[address]; answered {
+			return address
+		}
+	}
+	return ""
+}
+
+// Node with an elevator to send network-wide control commands to, the master if it answers. Observers
+// only take hall calls.
+func (mon *monitor) elevatorAddress() string {
+	address := ""
+	for _, a := range mon.addresses {
+		b, answered := mon.buildings[a]
+		if !answered || b.Searching || b.Role == "observer" {
+			continue
+		}
+		if b.Role == "master" {
+			return a
+		}
+		if address == "" {
+			address = a
+		}
+	}
+	return address
+}
+
+func (mon *monitor) draw() {
+	var b strings.Builder
+	address, view, found := mon.view()
+	if !found {
+		fmt.Fprintf(&b, "\x1b[1mElevator monitor\x1b[0m  %s\n\n", time.Now().Format("15:04:05"))
+		fmt.Fprintf(&b, "\x1b[31mNo node knows a master yet, asking %s\x1b[0m\n", strings.Join(mon.addresses, ", "))
+	} else {
+		fmt.Fprintf(&b, "\x1b[1mElevator monitor\x1b[0m  %s  via node %d (%s) at %s, master %d, epoch %d, version %d\n\n",
+			time.Now().Format("15:04:05"), view.NodeId, view.Role, address, view.MasterId, view.Epoch, view.Version)
+		drawShafts(&b, view)
+		b.WriteString("\n")
+		drawNodes(&b, view, mon.nodeAddresses())
+	}
+
+	b.WriteString("\n\x1b[1mEvents\x1b[0m\n")
+	for _, e := range mon.events {
+		fmt.Fprintf(&b, "  %s  node %-2d %-10s %s", e.Time.Local().Format("15:04:05.000"), e.Node, e.Kind, e.Order)
+		if e.Previous != nil {
+			fmt.Fprintf(&b, " from %d", *e.Previous)
+		}
+		if e.Assignee != nil {
+			fmt.Fprintf(&b, " to %d", *e.Assignee)
+		}
+		b.WriteString("\n")
+	}
+	for i := len(mon.events); i < *mon.flags.events; i++ {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\n\x1b[2m%s\x1b[0m\n", MONITOR_HELP)
+	fmt.Fprintf(&b, "%s\n> %s", mon.message, mon.input)
+	// Lines are overwritten in place and cleared after, which flickers less than clearing the screen first
+	os.Stdout.WriteString("\x1b[H" + strings.ReplaceAll(b.String(), "\n", "\x1b[K\n") + "\x1b[K\x1b[J")
+}
+
+// One row per floor, top floor first, with the hall buttons and a column per car
+func drawShafts(b *strings.Builder, view api.Building) {
+	b.WriteString("Floor  Hall ")
+	for _, s := range view.Shafts {
+		fmt.Fprintf(b, "  %-12s", fmt.Sprintf("Car %d", s.Id))
+	}
+	b.WriteString("\n")
+	for floor := view.Floors - 1; floor >= 0; floor-- {
+		fmt.Fprintf(b, "%5d  ", floor)
+		b.WriteString(hallButton(view, types.HallUp, floor, "▲"))
+		b.WriteString(hallButton(view, types.HallDown, floor, "▼"))
+		b.WriteString("   ")
+		for _, s := range view.Shafts {
+			b.WriteString("  ")
+			cell := ""
+			width := 12
+			if s.Floor == floor {
+				car := fmt.Sprintf("[%s]", direction(s.Direction))
+				if s.DoorOpen {
+					car = fmt.Sprintf("]%s[", direction(s.Direction))
+				}
+				color := "36"
+				if !s.Active {
+					color = "2"
+				} else if len(s.Blockers) > 0 {
+					color = "31"
+				}
+				cell += fmt.Sprintf("\x1b[%sm%s\x1b[0m ", color, car)
+				width -= 4
+			}
+			marks := ""
+			for _, o := range s.Orders {
+				if o.F == floor {
+					marks += orderMark(o)
+				}
+			}
+			for _, o := range s.Assigned {
+				if o.F == floor && !containsOrder(s.Orders, o) {
+					marks += orderMark(o)
+				}
+			}
+			cell += fmt.Sprintf("\x1b[33m%s\x1b[0m", marks)
+			width -= len([]rune(marks))
+			b.WriteString(cell + strings.Repeat(" ", max(width, 0)))
+		}
+		b.WriteString("\n")
+	}
+}
+
+func drawNodes(b *strings.Builder, view api.Building, apiAddresses map[int]string) {
+	b.WriteString("\x1b[1mNodes\x1b[0m\n")
+	for _, s := range view.Shafts {
+		role := "slave"
+		if s.Master {
+			role = "master"
+		}
+		if !s.Active {
+			role = "inactive"
+		}
+		door := "closed"
+		if s.DoorOpen {
+			door = "open"
+		}
+		apiAddress, found := apiAddresses[s.Id]
+		if !found {
+			apiAddress = "no status API"
+		}
+		fmt.Fprintf(b, "  %-2d %-8s %-21s floor %d %-4s door %-6s load %3d%%  %s", s.Id, role, s.Address, s.Floor, s.Direction, door, s.Load, apiAddress)
+		if len(s.Blockers) > 0 {
+			fmt.Fprintf(b, "  \x1b[31m%s\x1b[0m", strings.Join(s.Blockers, ", "))
+		}
+		b.WriteString("\n")
+	}
+	for _, id := range view.Observers {
+		fmt.Fprintf(b, "  %-2d %-8s %s\n", id, "observer", apiAddresses[id])
+	}
+}
+
+func hallButton(view api.Building, call types.Call, floor types.Floor, symbol string) string {
+	if (call == types.HallUp && floor == view.Floors-1) || (call == types.HallDown && floor == 0) {
+		return " "
+	}
+	if containsOrder(view.HallLights, types.Order{C: call, F: floor}) {
+		return "\x1b[33m" + symbol + "\x1b[0m"
+	}
+	return "\x1b[2m" + symbol + "\x1b[0m"
+}
+
+func direction(d string) string {
+	switch d {
+	case "up":
+		return "▲"
+	case "down":
+		return "▼"
+	}
+	return "■"
+}
+
+func orderMark(o types.Order) string {
+	switch o.C {
+	case types.HallUp:
+		return "▲"
+	case types.HallDown:
+		return "▼"
+	case types.Destination:
+		return "→" + strconv.Itoa(o.D)
+	}
+	return "●"
+}
+
+func containsOrder(orders []types.Order, order types.Order) bool {
+	for _, o := range orders {
+		if o == order {
+			return true
+		}
+	}
+	return false
+}
+
+// Carries out a typed command and describes the outcome. Hall calls go to any node that answers,
+// reassignment to a node with an elevator and everything else to the node it names.
+func runMonitorCommand(command []string, addresses map[int]string, hallAddress, elevatorAddress string) string {
+	number := func(i int) (int, error) {
+		if len(command) <= i {
+			return 0, fmt.Errorf("usage: %s", MONITOR_HELP)
+		}
+		return strconv.Atoi(command[i])
+	}
+	node := func() (string, error) {
+		id, err := number(1)
+		if err != nil {
+			return "", err
+		}
+		address, found := addresses[id]
+		if !found {
+			return "", fmt.Errorf("node %d has no status API that answers", id)
+		}
+		return address, nil
+	}
+
+	var err error
+	switch command[0] {
+	case "up", "down":
+		call := types.HallUp.String()
+		if command[0] == "down" {
+			call = types.HallDown.String()
+		}
+		var floor int
+		if floor, err = number(1); err == nil {
+			if hallAddress == "" {
+				err = fmt.Errorf("no node answers")
+			} else {
+				err = postForm(hallAddress, "/orders", url.Values{"call": {call}, "floor": {strconv.Itoa(floor)}})
+			}
+		}
+	case "car":
+		var address string
+		var floor int
+		if address, err = node(); err == nil {
+			if floor, err = number(2); err == nil {
+				err = postForm(address, "/orders", url.Values{"call": {types.Car.String()}, "floor": {strconv.Itoa(floor)}})
+			}
+		}
+	case "obstruct", "stop":
+		var address string
+		if address, err = node(); err == nil {
+			var e elevator.Elevator
+			if err = getJSON(address, "/elevator", nil, &e); err == nil {
+				path, active := "/simulate/obstruction", !e.Obstruction
+				if command[0] == "stop" {
+					path, active = "/simulate/stop", !e.Health.StopPressed
+				}
+				err = postForm(address, path, url.Values{"active": {strconv.FormatBool(active)}})
+				if err == nil {
+					return fmt.Sprintf("%s on node %s: %v", command[0], command[1], active)
+				}
+			}
+		}
+	case "kill":
+		var address string
+		if address, err = node(); err == nil {
+			err = postForm(address, "/kill", nil)
+		}
+	case "reassign":
+		if elevatorAddress == "" {
+			err = fmt.Errorf("no node with an elevator answers, give the status API of one on the command line")
+		} else {
+			err = postForm(elevatorAddress, "/reassign", nil)
+		}
+	default:
+		err = fmt.Errorf("unknown command %q, expected %s", command[0], MONITOR_HELP)
+	}
+	if err != nil {
+		return "\x1b[31m" + err.Error() + "\x1b[0m"
+	}
+	return strings.Join(command, " ") + ": done"
+}
+
+func getJSON(address, path string, query url.Values, value interface{}) error {
+	u := url.URL{Scheme: "http", Host: address, Path: path, RawQuery: query.Encode()}
+	response, err := monitorClient.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+func postForm(address, path string, form url.Values) error {
+	response, err := monitorClient.PostForm("http://"+address+path, form)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusAccepted {
+		message := make([]byte, 200)
+		n, _ := response.Body.Read(message)
+		return fmt.Errorf("%s: %s %s", path, response.Status, strings.TrimSpace(string(message[:n])))
+	}
+	return nil
+}
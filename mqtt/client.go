@@ -0,0 +1,273 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"project-group-81/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logging.For("mqtt")
+
+// Called with every message on a topic matching the filter it was subscribed with. Handlers are called
+// one at a time by the goroutine receiving messages, so they should return quickly.
+type Handler func(topic string, payload []byte)
+
+// Connection to a broker. Messages are published and received with QoS 0.
+type Client interface {
+	Publish(topic string, payload []byte, retain bool) error
+	Subscribe(filter string, handler Handler) error
+	Lost() <-chan error // Receives once when the connection is lost, and not after Close
+	Close() error
+}
+
+type Options struct {
+	ClientId  string
+	Username  string // Empty connects without credentials
+	Password  string
+	KeepAlive time.Duration
+}
+
+const CONNECT_TIMEOUT = time.Second * 10
+
+// Connection to a broker over TCP
+type client struct {
+	conn       net.Conn
+	keepAlive  time.Duration
+	writeMutex sync.Mutex
+	mutex      sync.Mutex
+	handlers   []subscription
+	subacks    map[uint16]chan error // Waiting Subscribe calls by packet id
+	nextId     uint16
+	lost       chan error
+	closing    bool
+	done       chan bool
+}
+
+type subscription struct {
+	filter  string
+	handler Handler
+}
+
+var connectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Connects to the broker at the address, starting a clean session
+func Dial(address string, options Options) (Client, error) {
+	conn, err := net.DialTimeout("tcp", address, CONNECT_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	c := &client{
+		conn:      conn,
+		keepAlive: options.KeepAlive,
+		subacks:   make(map[uint16]chan error),
+		lost:      make(chan error, 1),
+		done:      make(chan bool),
+	}
+
+	flags := byte(0x02) // Clean session
+	payload := appendString(nil, options.ClientId)
+	if options.Username != "" {
+		flags |= 0x80 | 0x40
+		payload = appendString(payload, options.Username)
+		payload = appendString(payload, options.Password)
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags) // Protocol level 4 is MQTT 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(options.KeepAlive/time.Second))
+	conn.SetDeadline(time.Now().Add(CONNECT_TIMEOUT))
+	if err := writePacket(conn, packet{kind: CONNECT, body: append(body, payload...)}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	ack, err := readPacket(reader)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ack.kind != CONNACK || len(ack.body) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("expected CONNACK, got packet type %d", ack.kind)
+	}
+	if code := ack.body[1]; code != 0 {
+		conn.Close()
+		if reason, found := connectErrors[code]; found {
+			return nil, fmt.Errorf("broker refused connection: %s", reason)
+		}
+		return nil, fmt.Errorf("broker refused connection with code %d", code)
+	}
+	conn.SetDeadline(time.Time{})
+
+	go c.receive(reader)
+	go c.ping()
+	return c, nil
+}
+
+func (c *client) write(p packet) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.keepAlive))
+	return writePacket(c.conn, p)
+}
+
+func (c *client) Publish(topic string, payload []byte, retain bool) error {
+	return c.write(publishPacket(topic, payload, retain))
+}
+
+// Subscribes and waits for the broker to accept the subscription
+func (c *client) Subscribe(filter string, handler Handler) error {
+	if err := ValidFilter(filter); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	c.nextId++
+	if c.nextId == 0 {
+		c.nextId = 1 // Zero is not a valid packet id
+	}
+	id := c.nextId
+	ack := make(chan error, 1)
+	c.subacks[id] = ack
+	c.handlers = append(c.handlers, subscription{filter, handler})
+	c.mutex.Unlock()
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = appendString(body, filter)
+	body = append(body, 0) // QoS 0
+	if err := c.write(packet{kind: SUBSCRIBE, flags: 0x02, body: body}); err != nil {
+		return err
+	}
+	select {
+	case err := <-ack:
+		return err
+	case <-time.After(c.keepAlive):
+		return fmt.Errorf("no answer to subscription to %s", filter)
+	case <-c.done:
+		return errors.New("connection closed")
+	}
+}
+
+func (c *client) Lost() <-chan error {
+	return c.lost
+}
+
+func (c *client) Close() error {
+	c.mutex.Lock()
+	c.closing = true
+	c.mutex.Unlock()
+	c.write(packet{kind: DISCONNECT})
+	return c.conn.Close()
+}
+
+// Reads packets until the connection fails, handing messages to the handlers of matching subscriptions
+func (c *client) receive(reader *bufio.Reader) {
+	defer close(c.done)
+	for {
+		// The broker answers pings sent every keep alive period, so a longer silence means it is gone
+		c.conn.SetReadDeadline(time.Now().Add(c.keepAlive * 3 / 2))
+		p, err := readPacket(reader)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch p.kind {
+		case PUBLISH:
+			topic, id, payload, err := parsePublish(p)
+			if err != nil {
+				c.fail(err)
+				return
+			}
+			if id != 0 { // QoS 1 from a broker that ignored the requested QoS
+				c.write(packet{kind: PUBACK, body: binary.BigEndian.AppendUint16(nil, id)})
+			}
+			c.mutex.Lock()
+			handlers := append([]subscription{}, c.handlers...)
+			c.mutex.Unlock()
+			for _, s := range handlers {
+				if Match(s.filter, topic) {
+					s.handler(topic, payload)
+				}
+			}
+		case SUBACK:
+			if len(p.body) < 3 {
+				c.fail(errors.New("malformed SUBACK"))
+				return
+			}
+			id := binary.BigEndian.Uint16(p.body)
+			c.mutex.Lock()
+			ack, found := c.subacks[id]
+			delete(c.subacks, id)
+			c.mutex.Unlock()
+			if found && p.body[2] == 0x80 {
+				ack <- errors.New("broker refused subscription")
+			} else if found {
+				ack <- nil
+			}
+		case PINGRESP:
+		default:
+			log.Debug("Ignoring packet", "type", p.kind)
+		}
+	}
+}
+
+func (c *client) fail(err error) {
+	c.conn.Close()
+	c.mutex.Lock()
+	closing := c.closing
+	c.mutex.Unlock()
+	if !closing {
+		c.lost <- err
+	}
+}
+
+func (c *client) ping() {
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write(packet{kind: PINGREQ}); err != nil {
+				c.conn.Close() // The receiver reports the failure
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Whether the topic matches the filter, where + matches one level and a trailing # any number of levels
+func Match(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+func ValidFilter(filter string) error {
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if (level == "#" && i != len(levels)-1) || (level != "#" && level != "+" && strings.ContainsAny(level, "+#")) {
+			return fmt.Errorf("invalid topic filter %q", filter)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package mqtt
+
+import (
+	"errors"
+	"sync"
+)
+
+// In-process stand-in for a broker, for running the bridge without one. It keeps retained messages and
+// delivers every message to the matching subscriptions of all its clients before Publish returns.
+type LocalBroker struct {
+	mutex    sync.Mutex
+	retained map[string][]byte
+	clients  map[*localClient]bool
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{retained: make(map[string][]byte), clients: make(map[*localClient]bool)}
+}
+
+// A new client connected to the broker
+func (b *LocalBroker) Client() Client {
+	c := &localClient{broker: b, lost: make(chan error)}
+	b.mutex.Lock()
+	b.clients[c] = true
+	b.mutex.Unlock()
+	return c
+}
+
+// The retained message of the topic, and whether there is one
+func (b *LocalBroker) Retained(topic string) ([]byte, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	payload, found := b.retained[topic]
+	return payload, found
+}
+
+type localClient struct {
+	broker        *LocalBroker
+	subscriptions []subscription // Guarded by the broker's mutex
+	lost          chan error
+	closed        bool
+}
+
+func (c *localClient) Publish(topic string, payload []byte, retain bool) error {
+	b := c.broker
+	b.mutex.Lock()
+	if c.closed {
+		b.mutex.Unlock()
+		return errors.New("client is closed")
+	}
+	if retain && len(payload) == 0 {
+		delete(b.retained, topic)
+	} else if retain {
+		b.retained[topic] = append([]byte{}, payload...)
+	}
+	var handlers []Handler
+	for client := range b.clients {
+		for _, s := range client.subscriptions {
+			if Match(s.filter, topic) {
+				handlers = append(handlers, s.handler)
+			}
+		}
+	}
+	b.mutex.Unlock()
+	for _, handler := range handlers {
+		handler(topic, payload)
+	}
+	return nil
+}
+
+// Subscribes and delivers the retained messages of matching topics at once
+func (c *localClient) Subscribe(filter string, handler Handler) error {
+	if err := ValidFilter(filter); err != nil {
+		return err
+	}
+	b := c.broker
+	b.mutex.Lock()
+	if c.closed {
+		b.mutex.Unlock()
+		return errors.New("client is closed")
+	}
+	c.subscriptions = append(c.subscriptions, subscription{filter, handler})
+	retained := make(map[string][]byte)
+	for topic, payload := range b.retained {
+		if Match(filter, topic) {
+			retained[topic] = payload
+		}
+	}
+	b.mutex.Unlock()
+	for topic, payload := range retained {
+		handler(topic, payload)
+	}
+	return nil
+}
+
+// Never receives, as the connection to an in-process broker cannot be lost
+func (c *localClient) Lost() <-chan error {
+	return c.lost
+}
+
+func (c *localClient) Close() error {
+	b := c.broker
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c.closed = true
+	delete(b.clients, c)
+	return nil
+}
@@ -0,0 +1,91 @@
+package mqtt
+
+import "testing"
+
+type message struct {
+	topic   string
+	payload string
+}
+
+func collect(messages *[]message) Handler {
+	return func(topic string, payload []byte) {
+		*messages = append(*messages, message{topic, string(payload)})
+	}
+}
+
+func TestLocalBrokerDelivers(t *testing.T) {
+	broker := NewLocalBroker()
+	subscriber, publisher := broker.Client(), broker.Client()
+	var received []message
+	if err := subscriber.Subscribe("elevators/car/+/state", collect(&received)); err != nil {
+		t.Fatal(err)
+	}
+	publisher.Publish("elevators/car/1/state", []byte("moving"), false)
+	publisher.Publish("elevators/car/1/alarm/obstructed", []byte("true"), false)
+	if len(received) != 1 || received[0] != (message{"elevators/car/1/state", "moving"}) {
+		t.Errorf("got %v", received)
+	}
+
+	subscriber.Close()
+	publisher.Publish("elevators/car/2/state", []byte("idle"), false)
+	if len(received) != 1 {
+		t.Errorf("closed client received %v", received[1:])
+	}
+	if err := subscriber.Publish("a", nil, false); err == nil {
+		t.Error("closed client published")
+	}
+}
+
+func TestLocalBrokerRetains(t *testing.T) {
+	broker := NewLocalBroker()
+	publisher := broker.Client()
+	publisher.Publish("elevators/hall/lights", []byte("[]"), true)
+	publisher.Publish("elevators/car/0/state", []byte("idle"), true)
+	publisher.Publish("elevators/hall/call", []byte("up"), false)
+
+	if payload, found := broker.Retained("elevators/hall/lights"); !found || string(payload) != "[]" {
+		t.Errorf("got %q, %v", payload, found)
+	}
+	if _, found := broker.Retained("elevators/hall/call"); found {
+		t.Error("message retained without the retain flag")
+	}
+
+	// Late subscribers get the retained messages of the topics they match at once
+	var received []message
+	broker.Client().Subscribe("elevators/hall/#", collect(&received))
+	if len(received) != 1 || received[0] != (message{"elevators/hall/lights", "[]"}) {
+		t.Errorf("got %v", received)
+	}
+
+	// An empty retained message clears the topic
+	publisher.Publish("elevators/car/0/state", nil, true)
+	if _, found := broker.Retained("elevators/car/0/state"); found {
+		t.Error("retained message not cleared")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct {
+		filter string
+		topic  string
+		match  bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+/c", "a/b/c", true},
+		{"a/+/c", "a/b/d/c", false},
+		{"a/+", "a", false},
+		{"a/#", "a/b/c", true},
+		{"#", "a", true},
+		{"a/b/c", "a/b", false},
+	} {
+		if Match(c.filter, c.topic) != c.match {
+			t.Errorf("Match(%q, %q) should be %v", c.filter, c.topic, c.match)
+		}
+	}
+	for _, filter := range []string{"a/#/b", "a/b+", "a#"} {
+		if ValidFilter(filter) == nil {
+			t.Errorf("%q accepted", filter)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Control packet types of MQTT 3.1.1, in the upper four bits of the first byte
+const (
+	CONNECT     byte = 1
+	CONNACK     byte = 2
+	PUBLISH     byte = 3
+	PUBACK      byte = 4
+	SUBSCRIBE   byte = 8
+	SUBACK      byte = 9
+	UNSUBSCRIBE byte = 10
+	UNSUBACK    byte = 11
+	PINGREQ     byte = 12
+	PINGRESP    byte = 13
+	DISCONNECT  byte = 14
+)
+
+const MAX_REMAINING_LENGTH = 268435455 // Largest length the four byte encoding can hold
+
+type packet struct {
+	kind  byte
+	flags byte // Lower four bits of the first byte
+	body  []byte
+}
+
+func writePacket(w io.Writer, p packet) error {
+	if len(p.body) > MAX_REMAINING_LENGTH {
+		return fmt.Errorf("packet of %d bytes is too large", len(p.body))
+	}
+	header := []byte{p.kind<<4 | p.flags&0x0f}
+	length := len(p.body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		header = append(header, digit)
+		if length == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(header, p.body...)) // One write, so packets from several goroutines do not mix
+	return err
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errors.New("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{kind: first >> 4, flags: first & 0x0f, body: body}, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// Reads a length-prefixed string and returns the rest of the bytes
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("truncated string")
+	}
+	length := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+length {
+		return "", nil, errors.New("truncated string")
+	}
+	return string(b[2 : 2+length]), b[2+length:], nil
+}
+
+// Splits the body of a PUBLISH packet. The packet id is zero for QoS 0.
+func parsePublish(p packet) (topic string, id uint16, payload []byte, err error) {
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if qos := (p.flags >> 1) & 0x03; qos > 0 {
+		if len(rest) < 2 {
+			return "", 0, nil, errors.New("truncated packet id")
+		}
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+	return topic, id, rest, nil
+}
+
+func publishPacket(topic string, payload []byte, retain bool) packet {
+	var flags byte
+	if retain {
+		flags = 0x01
+	}
+	return packet{kind: PUBLISH, flags: flags, body: append(appendString(nil, topic), payload...)}
+}
@@ -0,0 +1,80 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestPacketRoundTrip(t *testing.T) {
+	// Remaining lengths on both sides of where the encoding takes another byte
+	for _, length := range []int{0, 1, 127, 128, 16383, 16384, 2097151, 2097152} {
+		sent := packet{kind: PUBLISH, flags: 0x0b, body: bytes.Repeat([]byte{0xa5}, length)}
+		var wire bytes.Buffer
+		if err := writePacket(&wire, sent); err != nil {
+			t.Fatalf("length %d: %v", length, err)
+		}
+		if header := wire.Len() - length; header < 2 || header > 5 {
+			t.Errorf("length %d: header of %d bytes", length, header)
+		}
+		received, err := readPacket(bufio.NewReader(&wire))
+		if err != nil {
+			t.Fatalf("length %d: %v", length, err)
+		}
+		if received.kind != sent.kind || received.flags != sent.flags || !bytes.Equal(received.body, sent.body) {
+			t.Errorf("length %d: got kind %d, flags %x and %d bytes", length, received.kind, received.flags, len(received.body))
+		}
+	}
+}
+
+func TestPacketsInOneStream(t *testing.T) {
+	var wire bytes.Buffer
+	sent := []packet{{kind: PINGREQ}, publishPacket("a/b", []byte("hello"), true), {kind: DISCONNECT}}
+	for _, p := range sent {
+		if err := writePacket(&wire, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reader := bufio.NewReader(&wire)
+	for _, want := range sent {
+		got, err := readPacket(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.kind != want.kind || got.flags != want.flags || !bytes.Equal(got.body, want.body) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestMalformedRemainingLength(t *testing.T) {
+	wire := []byte{PINGREQ << 4, 0xff, 0xff, 0xff, 0xff, 0x01}
+	if _, err := readPacket(bufio.NewReader(bytes.NewReader(wire))); err == nil {
+		t.Error("five byte remaining length accepted")
+	}
+	truncated := []byte{PUBLISH << 4, 10, 0, 3, 'a'}
+	if _, err := readPacket(bufio.NewReader(bytes.NewReader(truncated))); err == nil {
+		t.Error("truncated body accepted")
+	}
+}
+
+func TestParsePublish(t *testing.T) {
+	topic, id, payload, err := parsePublish(publishPacket("elevators/hall/call", []byte(`{"Floor":1}`), true))
+	if err != nil || topic != "elevators/hall/call" || id != 0 || string(payload) != `{"Floor":1}` {
+		t.Errorf("QoS 0: got %q, %d, %q, %v", topic, id, payload, err)
+	}
+
+	// QoS 1 carries a packet id between the topic and the payload
+	body := append(appendString(nil, "t"), 0x12, 0x34, 'x')
+	topic, id, payload, err = parsePublish(packet{kind: PUBLISH, flags: 0x02, body: body})
+	if err != nil || topic != "t" || id != 0x1234 || string(payload) != "x" {
+		t.Errorf("QoS 1: got %q, %d, %q, %v", topic, id, payload, err)
+	}
+
+	if _, _, _, err := parsePublish(packet{kind: PUBLISH, flags: 0x02, body: appendString(nil, "t")}); err == nil {
+		t.Error("QoS 1 without packet id accepted")
+	}
+	if _, _, _, err := parsePublish(packet{kind: PUBLISH, body: []byte{0, 9, 'a'}}); err == nil {
+		t.Error("truncated topic accepted")
+	}
+}
@@ -1,8 +1,6 @@
 package network
 
 import (
-	"fmt"
-	"math"
 	"project-group-81/config"
 	"project-group-81/elevator"
 	"project-group-81/types"
@@ -56,7 +54,7 @@ func orderBetween(elevator elevator.Elevator, order, destination types.Order) bo
 		case types.MotorDown:
 			servableCall = types.HallDown
 		case types.MotorHalt:
-			fmt.Printf("Warning: Standby elevator should never call orderBetween")
+			log.Warn("Standby elevator should never call orderBetween")
 		}
 
 		if (order == types.Order{C: servableCall, F: currentFloor}) {
@@ -80,7 +78,7 @@ func orderBetween(elevator elevator.Elevator, order, destination types.Order) bo
 				simulatorDirection = types.MotorUp
 			}
 		case types.MotorHalt:
-			fmt.Printf("Warning: types.Standby elevator should never call orderBetween")
+			log.Warn("Standby elevator should never call orderBetween")
 		}
 	}
 	return false
@@ -89,7 +87,7 @@ func orderBetween(elevator elevator.Elevator, order, destination types.Order) bo
 func stopsBetween(destination types.Order, elevator elevator.Elevator) int {
 	stops := 0
 	for order := range elevator.Orders {
-		if orderBetween(elevator, order, destination) {
+		if orderBetween(elevator, order.Button(), destination) {
 			stops++
 		}
 	}
@@ -132,26 +130,35 @@ func distance(order types.Order, elevator elevator.Elevator) int {
 }
 
 func cost(order types.Order, elevator elevator.Elevator) int {
-	distanceCost := distance(order, elevator) * DISTANCE_COST
-	stopCost := stopsBetween(order, elevator) * STOPPING_COST
-	if elevator.Orders.Contains(types.Order{C: types.Car, F: order.F}) { // Discount if elevator is already stopping there
-		return distanceCost + stopCost - STOPPING_COST
-	} else {
-		return distanceCost + stopCost
+	floors, stops, discount := costTerms(order, elevator)
+	return floors*DISTANCE_COST + stops*STOPPING_COST - discount
+}
+
+// Floors travelled and stops made before reaching the order, and the discount given if the
+// elevator is already stopping there. Destination orders also count the floors on to the destination,
+// with another discount if the elevator already stops there.
+func costTerms(order types.Order, elevator elevator.Elevator) (floors, stops, discount int) {
+	pickup := order.Button()
+	floors = distance(pickup, elevator)
+	stops = stopsBetween(pickup, elevator)
+	if elevator.Orders.Contains(types.Order{C: types.Car, F: order.F}) {
+		discount = STOPPING_COST
+	}
+	if order.C == types.Destination {
+		floors += max(order.D-order.F, order.F-order.D)
+		if stopsAt(elevator, order.D) {
+			discount += STOPPING_COST
+		}
 	}
+	return floors, stops, discount
 }
 
-func Assign(order types.Order, processes []Process) AssignedOrder {
-	var cheapestProcess Process
-	minCost := math.MaxInt32
-	for _, p := range processes {
-		if p.Active {
-			cost := cost(order, p.Elevator)
-			if minCost > cost {
-				minCost = cost
-				cheapestProcess = p
-			}
+// Whether the elevator already stops on the floor to let passengers off
+func stopsAt(elevator elevator.Elevator, floor types.Floor) bool {
+	for order := range elevator.Orders {
+		if (order.C == types.Car && order.F == floor) || (order.C == types.Destination && order.D == floor) {
+			return true
 		}
 	}
-	return AssignedOrder{cheapestProcess.Id, order}
+	return false
 }
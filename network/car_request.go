@@ -0,0 +1,69 @@
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"project-group-81/types"
+	"time"
+)
+
+// Request for the elevator of one node. Other nodes send it to the master, which passes it on.
+type CarRequest struct {
+	Car       int
+	Order     *types.Order `json:",omitempty"` // Cab call to place
+	InService *bool        `json:",omitempty"` // Puts the elevator back in hall service, or takes it out
+}
+
+// Hands a request for this node's elevator to it
+func deliverCarRequest(request CarRequest, remoteButtonChan chan<- types.Order, serviceChan chan<- bool) {
+	if request.Order != nil {
+		log.Info("Placing requested cab call", "order", *request.Order)
+		go func(order types.Order) {
+			remoteButtonChan <- order
+		}(*request.Order)
+	}
+	if request.InService != nil {
+		log.Info("Changing service mode on request", "in_service", *request.InService)
+		go func(inService bool) {
+			serviceChan <- inService
+		}(*request.InService)
+	}
+}
+
+// Carries out a request for the master's own elevator, and passes any other on to the slave it is for
+func (n *NetworkNode) routeCarRequest(
+	request CarRequest,
+	slaveConnections map[int]net.Conn,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) {
+
+	if request.Car == n.Id {
+		deliverCarRequest(request, remoteButtonChan, serviceChan)
+		return
+	}
+	conn, found := slaveConnections[request.Car]
+	if !found || n.isObserver(request.Car) {
+		log.Warn("Dropping request for an elevator that is not connected", "peer", request.Car)
+		return
+	}
+	message, err := json.Marshal(request)
+	if err != nil {
+		log.Error("Failed to marshal car request", "err", err)
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(SLAVE_WRITE_TIMEOUT))
+	err = sendEncoded(conn, append([]byte{CAR_REQUEST_FLAG}, message...))
+	if err != nil {
+		log.Warn("Failed to send car request to slave", "peer", request.Car, "err", err)
+		conn.Close() // The failure detector takes it from here
+	}
+}
+
+// Sends a request for the elevator of another node to the master
+func sendCarRequest(masterConn net.Conn, request CarRequest) error {
+	message, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	return patientWrite(masterConn, append([]byte{CAR_REQUEST_FLAG}, message...), MASTER_RESPONSE_TIMEOUT)
+}
@@ -1,33 +1,51 @@
 package network
 
-import "time"
+import (
+	"project-group-81/logging"
+	"time"
+)
+
+var log = logging.For("network")
 
 const (
 	//Flags in messages sent from master
 	ASSIGNED_ORDERS_FLAG byte = 0
 	PROCESSES_FLAG       byte = 1
 	CONFIRMATION_FLAG    byte = 2
+	ORDERS_DELTA_FLAG    byte = 7
+	PARK_FLAG            byte = 10
 	//Flags in messages sent from slave
-	NEW_ORDER_FLAG      byte = 3
-	FINISHED_ORDER_FLAG byte = 4
-	ELEVATOR_STATE_FLAG byte = 5
-
-	INTERFACE_NAME         string = "wlp1s0"
-	NETWORK                string = "tcp4"
-	BROADCAST_NETWORK      string = "udp"
-	BROADCAST_ADDRESS      string = "255.255.255.255"
-	POLISH_POPE_DEATH_PORT string = "2137"
-
-	MASTER_RESPONSE_TIMEOUT = time.Second * 10 // Time before slave assumes master to be dead
-	MASTER_SEARCH_TIMEOUT   = time.Second * 5  // Time before node is assumed not to be master
-	SLAVE_WRITE_TIMEOUT     = time.Second * 2  // Time before master assumes slave to be dead
+	NEW_ORDER_FLAG        byte = 3
+	FINISHED_ORDER_FLAG   byte = 4
+	ELEVATOR_STATE_FLAG   byte = 5
+	ORDERS_DIGEST_FLAG    byte = 8
+	SNAPSHOT_REQUEST_FLAG byte = 9
+	REASSIGN_REQUEST_FLAG byte = 11
+	//Flags in messages sent both ways
+	HEARTBEAT_FLAG   byte = 6
+	CAR_REQUEST_FLAG byte = 12
+
+	NETWORK           string = "tcp"
+	BROADCAST_NETWORK string = "udp"
+	BROADCAST_ADDRESS string = "255.255.255.255" // Used when the interface has no directed broadcast address
+
+	MASTER_RESPONSE_TIMEOUT = time.Second * 10       // Time before slave assumes master to be dead
+	MASTER_SEARCH_TIMEOUT   = time.Second * 5        // Time before node is assumed not to be master
+	SLAVE_WRITE_TIMEOUT     = time.Second * 2        // Time before master assumes slave to be dead
+	MASTER_DIAL_PERIOD      = time.Millisecond * 100 // Time between attempts to reach the next master after a failover
+	HANDSHAKE_TIMEOUT       = time.Second * 2        // Time a new connection has to send its elevator socket to the master
 
 	MASTER_BROADCAST_PERIOD = time.Second     // Master network information broadcast period
 	MASTER_INFO_PERIOD      = time.Second * 5 // Connected slave broadcast
+	PARKING_CHECK_PERIOD    = time.Second     // Period between searches for idle elevators to park
+	HALL_WAIT_CHECK_PERIOD  = time.Second     // Period between searches for hall calls waiting longer than WEBHOOK_HALL_WAIT
 
 	MASTER_PROMOTION_TIME = MASTER_SEARCH_TIMEOUT * 3 // Highest expected master promotion time
 
-	MESSAGE_BUFFER_LENGTH = 2048
+	MESSAGE_BUFFER_LENGTH = 2048    // Bytes read from a connection at a time
+	MAX_PACKET_LENGTH     = 1 << 20 // Longest packet accepted before the connection counts as broken
+
+	OBSERVER_HANDSHAKE = "observer " // Sent before the identity socket by observers joining a master, instead of the elevator socket
 )
 
 func PacketDelimiter() []byte {
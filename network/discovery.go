@@ -0,0 +1,131 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"project-group-81/config"
+	"strconv"
+
+	"github.com/projecthunt/reuseable"
+)
+
+// Announced periodically by the master so new nodes can find it
+type Beacon struct {
+	ClusterId string
+	Epoch     int    // Increased every time a node takes over as master
+	Endpoint  string // TCP socket the master accepts slaves on
+}
+
+// Sends beacons using the transport selected by config.DISCOVERY_TRANSPORT
+type announcer interface {
+	announce(beacon []byte) error
+	close()
+}
+
+type udpAnnouncer struct {
+	connections []net.Conn
+}
+
+func (a *udpAnnouncer) announce(beacon []byte) error {
+	var lastErr error
+	for _, connection := range a.connections {
+		if _, err := connection.Write(beacon); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (a *udpAnnouncer) close() {
+	for _, connection := range a.connections {
+		connection.Close()
+	}
+}
+
+func discoveryAddress(host string) string {
+	return net.JoinHostPort(host, config.DISCOVERY_PORT)
+}
+
+func multicastGroup() (*net.UDPAddr, error) {
+	port, err := strconv.Atoi(config.DISCOVERY_PORT)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(config.DISCOVERY_GROUP)
+	if ip == nil || !ip.IsMulticast() {
+		return nil, fmt.Errorf("%s is not a multicast group", config.DISCOVERY_GROUP)
+	}
+	group := &net.UDPAddr{IP: ip, Port: port}
+	if ip.To4() == nil {
+		group.Zone = link.iface.Name // Link-local IPv6 groups need to know the interface
+	}
+	return group, nil
+}
+
+func newAnnouncer() (announcer, error) {
+	switch config.DISCOVERY_TRANSPORT {
+	case "broadcast":
+		connection, err := net.Dial(BROADCAST_NETWORK, discoveryAddress(broadcastAddress()))
+		if err != nil {
+			return nil, err
+		}
+		return &udpAnnouncer{[]net.Conn{connection}}, nil
+	case "multicast":
+		group, err := multicastGroup()
+		if err != nil {
+			return nil, err
+		}
+		connection, err := net.DialUDP(BROADCAST_NETWORK, nil, group)
+		if err != nil {
+			return nil, err
+		}
+		return &udpAnnouncer{[]net.Conn{connection}}, nil
+	case "static":
+		a := &udpAnnouncer{}
+		for _, peer := range config.DISCOVERY_PEERS {
+			connection, err := net.Dial(BROADCAST_NETWORK, peer)
+			if err != nil {
+				log.Warn("Failed to resolve discovery peer", "peer", peer, "err", err)
+				continue
+			}
+			a.connections = append(a.connections, connection)
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("unknown discovery transport %q", config.DISCOVERY_TRANSPORT)
+}
+
+// Opens the socket beacons from the master arrive on
+func listenForBeacons() (net.PacketConn, error) {
+	switch config.DISCOVERY_TRANSPORT {
+	case "broadcast", "static":
+		return reuseable.ListenPacket(BROADCAST_NETWORK, discoveryAddress(""))
+	case "multicast":
+		group, err := multicastGroup()
+		if err != nil {
+			return nil, err
+		}
+		return net.ListenMulticastUDP(BROADCAST_NETWORK, &link.iface, group)
+	}
+	return nil, fmt.Errorf("unknown discovery transport %q", config.DISCOVERY_TRANSPORT)
+}
+
+func (n *NetworkNode) beacon() []byte {
+	beacon := Beacon{config.CLUSTER_ID, n.Epoch, n.getOwnProcess().Socket.String()}
+	message, err := json.Marshal(beacon)
+	if err != nil {
+		log.Error("Failed to marshal beacon", "err", err)
+	}
+	return message
+}
+
+// Returns the beacon if the message is a valid beacon from a master in our cluster
+func parseBeacon(message []byte) (Beacon, bool) {
+	var beacon Beacon
+	if err := json.Unmarshal(message, &beacon); err != nil {
+		return beacon, false
+	}
+	endpoint := FromString(beacon.Endpoint)
+	return beacon, beacon.ClusterId == config.CLUSTER_ID && endpoint.Valid()
+}
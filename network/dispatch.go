@@ -0,0 +1,185 @@
+package network
+
+import (
+	"fmt"
+	"math"
+	"project-group-81/elevator"
+	"project-group-81/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Decides which elevator serves a hall order, given a snapshot of every known process
+type Assigner interface {
+	Assign(order types.Order, processes []Process) AssignedOrder
+	Explain(order types.Order, processes []Process) Explanation // The decision Assign makes, and why
+	Cost(order types.Order, elevator elevator.Elevator) int     // Lower is better
+}
+
+// Assigns the order to the active, healthy elevator with the lowest cost
+type costAssigner struct {
+	name string
+	cost func(order types.Order, elevator elevator.Elevator) int
+}
+
+func (a costAssigner) Cost(order types.Order, elevator elevator.Elevator) int {
+	return a.cost(order, elevator)
+}
+
+func (a costAssigner) Assign(order types.Order, processes []Process) AssignedOrder {
+	return AssignedOrder{a.Explain(order, processes).Chosen, order}
+}
+
+// Costs every elevator, including those that are not candidates, so they can be compared
+func (a costAssigner) Explain(order types.Order, processes []Process) Explanation {
+	explanation := Explanation{Time: time.Now(), Order: order, Strategy: a.name}
+	isCandidate := make(map[int]bool)
+	for _, p := range candidates(order, processes) {
+		isCandidate[p.Id] = true
+	}
+	minCost := math.MaxInt32
+	for _, p := range processes {
+		if p.Observer {
+			continue
+		}
+		floors, stops, discount := costTerms(order, p.Elevator)
+		candidate := CandidateCost{
+			Id:           p.Id,
+			Floors:       floors,
+			StopsBetween: stops,
+			Discount:     discount,
+			Cost:         a.cost(order, p.Elevator),
+		}
+		if !p.Active {
+			candidate.Excluded = "inactive"
+		} else if !isCandidate[p.Id] {
+			reasons := p.Elevator.HallOrderBlockers()
+			if !p.Elevator.ServesOrder(order) {
+				reasons = append([]string{"floor not served"}, reasons...)
+			}
+			candidate.Excluded = strings.Join(reasons, ", ")
+		} else if minCost > candidate.Cost {
+			minCost = candidate.Cost
+			explanation.Chosen = p.Id
+		}
+		explanation.Candidates = append(explanation.Candidates, candidate)
+	}
+	return explanation
+}
+
+// Assigns a new order with the configured strategy. A destination order goes to a car that already
+// picks up passengers on the same floor going the same way and stops on the same destination floor, so
+// the passengers travel together without adding a stop. Otherwise the strategy decides, and the
+// "cost" strategy includes the trip to the destination.
+func (n *NetworkNode) assign(order types.Order, reason string) AssignedOrder {
+	explanation := n.assigner.Explain(order, n.Processes)
+	explanation.Reason = reason
+	if order.C == types.Destination {
+		for _, assigned := range n.AssignedOrders {
+			if assigned.Order.C == types.Destination && assigned.Order.Button() == order.Button() &&
+				n.stopsAt(assigned.Id, order.D) && isCandidate(assigned.Id, order, n.Processes) {
+				explanation.Chosen = assigned.Id
+				explanation.Reason = reason + fmt.Sprintf(", grouped with passengers from floor %d to floor %d", order.F, order.D)
+				break
+			}
+		}
+	}
+	recordExplanation(explanation)
+	return AssignedOrder{explanation.Chosen, order}
+}
+
+// Whether the elevator of the node already stops on the floor to let passengers off, counting
+// destination orders assigned to it that it has not taken yet
+func (n *NetworkNode) stopsAt(id int, floor types.Floor) bool {
+	for _, assigned := range n.AssignedOrders {
+		if assigned.Id == id && assigned.Order.C == types.Destination && assigned.Order.D == floor {
+			return true
+		}
+	}
+	for _, p := range n.Processes {
+		if p.Id == id {
+			return stopsAt(p.Elevator, floor)
+		}
+	}
+	return false
+}
+
+func isCandidate(id int, order types.Order, processes []Process) bool {
+	for _, p := range candidates(order, processes) {
+		if p.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Active processes with an elevator that serves the floors of the order and accepts hall orders. Faulty or full
+// elevators come next, and elevators serving other floors last, since any elevator is better than
+// losing the order.
+func candidates(order types.Order, processes []Process) []Process {
+	var healthy, serving, active []Process
+	for _, p := range processes {
+		if !p.Active || p.Observer {
+			continue
+		}
+		active = append(active, p)
+		if !p.Elevator.ServesOrder(order) {
+			continue
+		}
+		serving = append(serving, p)
+		if p.Elevator.AcceptsHallOrders() {
+			healthy = append(healthy, p)
+		}
+	}
+	if len(healthy) > 0 {
+		return healthy
+	} else if len(serving) > 0 {
+		return serving
+	}
+	return active
+}
+
+// Number of floors between the elevator and the order, ignoring direction and other orders
+func nearestCarCost(order types.Order, elevator elevator.Elevator) int {
+	if order.F > elevator.LastFloor {
+		return order.F - elevator.LastFloor
+	}
+	return elevator.LastFloor - order.F
+}
+
+// Simulated time in milliseconds until the door opens for the order
+func waitTimeCost(order types.Order, elevator elevator.Elevator) int {
+	return int(elevator.TimeToServe(order).Wait.Milliseconds())
+}
+
+// Simulated time in milliseconds until the elevator has served all its orders, including this one
+func completionTimeCost(order types.Order, elevator elevator.Elevator) int {
+	return int(elevator.TimeToServe(order).Completion.Milliseconds())
+}
+
+var strategies = map[string]Assigner{
+	"nearest":         costAssigner{"nearest", nearestCarCost},
+	"cost":            costAssigner{"cost", cost},
+	"wait-time":       costAssigner{"wait-time", waitTimeCost},
+	"completion-time": costAssigner{"completion-time", completionTimeCost},
+}
+
+const DEFAULT_STRATEGY = "cost"
+
+func NewAssigner(strategy string) (Assigner, error) {
+	if assigner, found := strategies[strategy]; found {
+		return assigner, nil
+	}
+	return nil, fmt.Errorf("unknown dispatch strategy %q, expected one of %v", strategy, Strategies())
+}
+
+// Names of every available dispatch strategy
+func Strategies() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
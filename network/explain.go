@@ -0,0 +1,75 @@
+package network
+
+import (
+	"fmt"
+	"project-group-81/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of assignment decisions kept for Explanations
+const EXPLANATION_HISTORY = 100
+
+// What one process would have cost in an assignment decision. Floors, StopsBetween and Discount are
+// the terms of the "cost" strategy and are filled in whichever strategy is used.
+type CandidateCost struct {
+	Id           int
+	Excluded     string // Why the process could not take the order, empty if it was a candidate
+	Floors       int    // Floors travelled before reaching the order, and on to the destination of destination orders
+	StopsBetween int    // Stops made before reaching the order
+	Discount     int    // Taken off the cost when the elevator already stops on the floor, or on the destination floor
+	Cost         int    // Final cost under the strategy used, lower is better
+}
+
+// Why an order was given to an elevator
+type Explanation struct {
+	Time       time.Time
+	Order      types.Order
+	Reason     string // What made the master assign the order
+	Strategy   string
+	Chosen     int
+	Candidates []CandidateCost
+}
+
+func (e Explanation) String() string {
+	return fmt.Sprintf("Assigned %s to node %d (%s, %s strategy): %s",
+		e.Order, e.Chosen, e.Reason, e.Strategy, e.candidateSummary())
+}
+
+// What every candidate would have cost, or why it was excluded
+func (e Explanation) candidateSummary() string {
+	var candidates []string
+	for _, c := range e.Candidates {
+		if c.Excluded != "" {
+			candidates = append(candidates, fmt.Sprintf("node %d excluded (%s)", c.Id, c.Excluded))
+		} else {
+			candidates = append(candidates, fmt.Sprintf("node %d cost %d (%d floors, %d stops, discount %d)",
+				c.Id, c.Cost, c.Floors, c.StopsBetween, c.Discount))
+		}
+	}
+	return strings.Join(candidates, "; ")
+}
+
+var explanations = struct {
+	sync.Mutex
+	records []Explanation
+}{}
+
+func recordExplanation(e Explanation) {
+	log.Info("Assigned order", "order", e.Order, "peer", e.Chosen, "reason", e.Reason, "strategy", e.Strategy,
+		"candidates", e.candidateSummary())
+	explanations.Lock()
+	defer explanations.Unlock()
+	explanations.records = append(explanations.records, e)
+	if len(explanations.records) > EXPLANATION_HISTORY {
+		explanations.records = explanations.records[len(explanations.records)-EXPLANATION_HISTORY:]
+	}
+}
+
+// The latest assignment decisions made while this node was master, oldest first
+func Explanations() []Explanation {
+	explanations.Lock()
+	defer explanations.Unlock()
+	return append([]Explanation{}, explanations.records...)
+}
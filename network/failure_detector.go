@@ -0,0 +1,111 @@
+package network
+
+import (
+	"net"
+	"project-group-81/config"
+	"sync"
+	"time"
+)
+
+// Key used by slaves for the single peer they are watching.
+const MASTER_PEER_ID = -1
+
+type livenessEvent struct {
+	Id    int
+	Alive bool
+}
+
+// Timeout-based failure detector. Every message received from a peer counts as a heartbeat.
+// A peer that has been silent for longer than SUSPECT_TIMEOUT is suspected once, and declared
+// alive again on the next heartbeat. Transitions are published on the events channel.
+type failureDetector struct {
+	mutex     sync.Mutex
+	lastHeard map[int]time.Time
+	suspected map[int]bool
+	events    chan livenessEvent
+	done      chan bool
+}
+
+func newFailureDetector() *failureDetector {
+	fd := &failureDetector{
+		lastHeard: make(map[int]time.Time),
+		suspected: make(map[int]bool),
+		events:    make(chan livenessEvent),
+		done:      make(chan bool)}
+	go fd.run()
+	return fd
+}
+
+func (fd *failureDetector) heartbeat(id int) {
+	fd.mutex.Lock()
+	fd.lastHeard[id] = time.Now()
+	wasSuspected := fd.suspected[id]
+	delete(fd.suspected, id)
+	fd.mutex.Unlock()
+	if wasSuspected {
+		fd.publish(livenessEvent{id, true})
+	}
+}
+
+// Stops watching a peer, e.g. after its connection has been closed
+func (fd *failureDetector) forget(id int) {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	delete(fd.lastHeard, id)
+	delete(fd.suspected, id)
+}
+
+func (fd *failureDetector) stop() {
+	close(fd.done)
+}
+
+func (fd *failureDetector) publish(event livenessEvent) {
+	select {
+	case fd.events <- event:
+	case <-fd.done:
+	}
+}
+
+func (fd *failureDetector) run() {
+	ticker := time.NewTicker(config.SUSPECT_TIMEOUT / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fd.done:
+			return
+		case now := <-ticker.C:
+			var newlySuspected []int
+			fd.mutex.Lock()
+			for id, t := range fd.lastHeard {
+				if !fd.suspected[id] && now.Sub(t) > config.SUSPECT_TIMEOUT {
+					fd.suspected[id] = true
+					newlySuspected = append(newlySuspected, id)
+				}
+			}
+			fd.mutex.Unlock()
+			for _, id := range newlySuspected {
+				fd.publish(livenessEvent{id, false})
+			}
+		}
+	}
+}
+
+// Sends heartbeats on the connection every HEARTBEAT_PERIOD until done is closed or a write fails, in
+// which case the connection is closed so that its reader reports the peer as lost. Heartbeats have a
+// goroutine of their own, so a peer that is slow to take other messages does not hold them up.
+func sendHeartbeats(conn net.Conn, done <-chan bool) {
+	ticker := time.NewTicker(config.HEARTBEAT_PERIOD)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := patientWrite(conn, []byte{HEARTBEAT_FLAG}, config.SUSPECT_TIMEOUT); err != nil {
+				log.Warn("Failed to send heartbeat", "peer", conn.RemoteAddr().String(), "err", err)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"project-group-81/config"
 	"project-group-81/elevator"
 	"project-group-81/types"
 	"time"
@@ -11,26 +12,36 @@ import (
 	"github.com/projecthunt/reuseable"
 )
 
+// Searches for the next master after losing lostMaster, -1 if this node was the master
 func (n *NetworkNode) ReinitializeNode(
+	lostMaster int,
 	newOrderChan,
 	finishedOrderChan chan types.Order,
 	lightOnChan,
 	lightOffChan chan<- types.Order,
 	stateChan chan elevator.Elevator,
-	assignedOrderChan chan<- types.Order) {
-
-	fmt.Printf("Reinitializing node.\n")
-
-	for !interfaceAvailable() {
-
-	}
-
-	conn := n.findNewMaster()
+	assignedOrderChan,
+	revokedOrderChan chan<- types.Order,
+	connectedChan chan<- bool,
+	parkChan chan<- types.Floor,
+	statusRequestChan <-chan chan Status,
+	reassignChan <-chan bool,
+	carRequestChan <-chan CarRequest,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) {
+
+	n.announceRole("searching")
+	log.Info("Reinitializing node")
+	connectedChan <- false
+
+	waitForInterface()
+
+	conn, packets := n.findNewMaster(lostMaster)
 	if conn == nil {
-		fmt.Printf("Failed to find new master. Turning into master.\n")
-		n.masterRun(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+		log.Info("Failed to find new master, turning into master")
+		n.masterRun(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
 	} else {
-		n.slaveRun(conn, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+		n.slaveRun(conn, packets, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
 	}
 }
 
@@ -41,48 +52,58 @@ func InitializeNode(
 	lightOnChan,
 	lightOffChan chan<- types.Order,
 	stateChan chan elevator.Elevator,
-	assignedOrderChan chan<- types.Order) {
-
-	fmt.Printf("Waiting %d seconds before initializing network node.\n", int(MASTER_PROMOTION_TIME.Seconds()))
+	assignedOrderChan,
+	revokedOrderChan chan<- types.Order,
+	connectedChan chan<- bool,
+	parkChan chan<- types.Floor,
+	statusRequestChan <-chan chan Status,
+	reassignChan <-chan bool,
+	carRequestChan <-chan CarRequest,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) {
+
+	log.Info("Waiting before initializing network node", "delay", MASTER_PROMOTION_TIME)
 	time.Sleep(MASTER_PROMOTION_TIME)
 
 	freePort, _ := getFreePort()
-	process := Process{0, Socket{hwSocket.Address, fmt.Sprint(freePort)}, true, hwSocket, elevator.DefaultElevator()}
-	networkNode := NetworkNode{0, []AssignedOrder{}, []AssignedOrder{}, []Process{process}}
-
-	for !interfaceAvailable() {
-	} // Wait here till network interface is available
+	process := Process{
+		Id:             0,
+		Socket:         Socket{hwSocket.Address, fmt.Sprint(freePort)},
+		Active:         true,
+		ElevatorSocket: hwSocket,
+		Elevator:       elevator.DefaultElevator()}
+	assigner, err := NewAssigner(config.DISPATCH_STRATEGY)
+	if err != nil {
+		log.Warn("Invalid dispatch strategy, using the default", "err", err, "strategy", DEFAULT_STRATEGY)
+		assigner = strategies[DEFAULT_STRATEGY]
+	}
+	networkNode := NetworkNode{
+		Id:                     0,
+		AssignedOrders:         []AssignedOrder{},
+		PreviousAssignedOrders: []AssignedOrder{},
+		Processes:              []Process{process},
+		assigner:               assigner}
+
+	if err := startInterfaceMonitor(); err != nil {
+		log.Error("Failed to find a network interface", "err", err)
+		return
+	}
+	waitForInterface()
 
 	lsocket := networkNode.getOwnProcess().Socket.String()
-	rsocket, err := initialSearchForMaster()
+	beacon, err := initialSearchForMaster(0)
 	if err != nil {
-		go networkNode.masterRun(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+		go networkNode.masterRun(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
 		return
 	}
 
-	fmt.Printf("Master suggests connection on socket %s\n", rsocket)
-	conn, err := reuseable.DialTimeout(NETWORK, lsocket, rsocket, MASTER_RESPONSE_TIMEOUT)
+	log.Info("Master suggests connection", "peer", beacon.Endpoint)
+	networkNode.Epoch = beacon.Epoch
+	conn, err := reuseable.DialTimeout(NETWORK, lsocket, beacon.Endpoint, MASTER_RESPONSE_TIMEOUT)
 	if err == nil {
-		conn.SetWriteDeadline(time.Now().Add(MASTER_RESPONSE_TIMEOUT))
-		_, err := conn.Write([]byte(hwSocket.String()))
-		if err != nil {
-			panic("Error writing hardware socket to master in InitializeNode.\n")
-		}
-		buf := make([]byte, MESSAGE_BUFFER_LENGTH)
-		length, err := patientRead(conn, buf, MASTER_RESPONSE_TIMEOUT)
-		if err != nil {
-			panic("Error reading processes response from master in InitializeNode.\n")
-		}
-		err = json.Unmarshal(buf[:length], &networkNode.Processes)
+		packets, err := networkNode.join(conn, hwSocket.String(), hwSocket)
 		if err != nil {
-			panic("Error unmarshalling processes response from master in InitializeNode.\n")
-		}
-
-		// Extract id if elevator was previously active
-		for _, p := range networkNode.Processes {
-			if p.ElevatorSocket.Equals(hwSocket) {
-				networkNode.Id = p.Id
-			}
+			panic(fmt.Sprintf("Error joining master in InitializeNode: %v\n", err))
 		}
 
 		// Resend assigned orders
@@ -94,50 +115,122 @@ func InitializeNode(
 			}
 		}(networkNode.getOwnProcess().Elevator.Orders)
 
-		go networkNode.slaveRun(conn, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+		go networkNode.slaveRun(conn, packets, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
 		return
 	} else { // Should happen very rarely, only if death between broadcast and connection attempt
-		fmt.Printf("Master died between broadcast and connection attempt!\n")
-		go networkNode.masterRun(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+		log.Warn("Master died between broadcast and connection attempt", "peer", beacon.Endpoint, "err", err)
+		go networkNode.masterRun(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
 		return
 	}
 }
 
-func (n *NetworkNode) findNewMaster() net.Conn {
-	lsocket := n.getOwnProcess().Socket.String()
-	for _, process := range n.Processes {
-		time.Sleep(3 * time.Second)
-		rsocket := process.Socket.String()
-		fmt.Printf("Trying to connect to %s\n", rsocket)
+// Sends the handshake to the master and takes the processes it answers with, along with the id of the
+// process with the identity, which is kept from earlier visits
+func (n *NetworkNode) join(conn net.Conn, handshake string, identity Socket) (*packetReader, error) {
+	conn.SetWriteDeadline(time.Now().Add(MASTER_RESPONSE_TIMEOUT))
+	if err := sendEncoded(conn, []byte(handshake)); err != nil {
+		return nil, err
+	}
+	packets := masterPacketReader(conn, MASTER_RESPONSE_TIMEOUT)
+	message, err := packets.next()
+	if err != nil {
+		return nil, err
+	}
+	var processes []Process
+	if err := json.Unmarshal(message, &processes); err != nil {
+		return nil, err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	n.Processes = processes
+	for _, p := range processes {
+		if p.ElevatorSocket.Equals(identity) {
+			n.Id = p.Id
+		}
+	}
+	return packets, nil
+}
+
+// Joins the first active elevator in the list of processes, which every node takes to be the next
+// master. A candidate only listens once it has noticed the loss of the old master itself, so it is
+// dialed again until MASTER_SEARCH_TIMEOUT before moving on to the next one. The lost master is tried
+// once, as it is still listening if only this node lost contact with it. Returns nil when this node is
+// the one to take over.
+func (n *NetworkNode) findNewMaster(lostMaster int) (net.Conn, *packetReader) {
+	mutex.Lock()
+	processes := append([]Process{}, n.Processes...)
+	own := n.getOwnProcess()
+	mutex.Unlock()
+	lsocket := own.Socket.String()
+	for _, process := range processes {
+		if process.Observer || !process.Active {
+			continue
+		}
 		if n.Id == process.Id {
-			return nil
-		} else {
-			time.Sleep(2 * time.Second)
-			if conn, err := reuseable.DialTimeout(NETWORK, lsocket, rsocket, MASTER_SEARCH_TIMEOUT); err == nil {
-				return conn
+			return nil, nil
+		}
+		rsocket := process.Socket.String()
+		log.Info("Trying to connect to master", "peer", rsocket)
+		deadline := time.Now().Add(MASTER_SEARCH_TIMEOUT)
+		if process.Id == lostMaster {
+			deadline = time.Now()
+		}
+		for {
+			conn, err := reuseable.DialTimeout(NETWORK, lsocket, rsocket, max(time.Until(deadline), config.SUSPECT_TIMEOUT))
+			if err == nil {
+				packets, err := n.join(conn, own.ElevatorSocket.String(), own.ElevatorSocket)
+				if err == nil {
+					return conn, packets
+				}
+				log.Warn("Failed to join new master", "peer", rsocket, "err", err)
+				conn.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				break
 			}
+			time.Sleep(MASTER_DIAL_PERIOD)
 		}
 	}
-	return nil
+	return nil, nil
 }
 
-func initialSearchForMaster() (string, error) {
-	fmt.Printf("Starting initial search for master.\n")
-	localAddress := fmt.Sprintf(":%s", POLISH_POPE_DEATH_PORT)
-	connection, err := reuseable.ListenPacket(BROADCAST_NETWORK, localAddress)
+// Listens for beacons of masters with at least the given epoch. After the first beacon it listens for
+// one more broadcast period, so that when a partition has left two masters the newest one is joined.
+func initialSearchForMaster(minEpoch int) (Beacon, error) {
+	log.Info("Starting initial search for master", "discovery", config.DISCOVERY_TRANSPORT)
+	connection, err := listenForBeacons()
 	if err != nil {
-		fmt.Printf("ListenPacket error: %v\n", err)
-		return "", err
+		log.Error("Failed to listen for beacons", "err", err)
+		return Beacon{}, err
 	}
+	defer connection.Close()
 	buf := make([]byte, MESSAGE_BUFFER_LENGTH)
+	connection.SetReadDeadline(time.Now().Add(MASTER_SEARCH_TIMEOUT))
+	var best Beacon
+	found := false
 	for {
-		connection.SetReadDeadline(time.Now().Add(MASTER_SEARCH_TIMEOUT))
 		length, _, err := connection.ReadFrom(buf)
-		if err == nil && length != 0 {
-			fmt.Printf("Got initial message from master: %s\n", buf[:length])
-			return string(buf[:length]), nil
+		if err != nil && found {
+			log.Info("Got beacon from master", "peer", best.Endpoint, "epoch", best.Epoch)
+			return best, nil
 		} else if err != nil {
-			return "", err
+			return Beacon{}, err
+		}
+		beacon, valid := parseBeacon(buf[:length])
+		if !valid {
+			continue
+		}
+		if beacon.Epoch < minEpoch {
+			log.Debug("Ignoring beacon from stale master", "peer", beacon.Endpoint, "epoch", beacon.Epoch, "known_epoch", minEpoch)
+			continue
+		}
+		if !found || beacon.Epoch > best.Epoch {
+			best = beacon
+		}
+		if !found {
+			found = true
+			connection.SetReadDeadline(time.Now().Add(MASTER_BROADCAST_PERIOD))
 		}
 	}
 }
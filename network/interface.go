@@ -0,0 +1,185 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"project-group-81/config"
+	"sync"
+	"time"
+)
+
+// Link state of the selected interface, kept up to date by monitorInterface
+var link struct {
+	mutex     sync.Mutex
+	started   bool
+	iface     net.Interface
+	available bool
+	up        chan bool // Closed while the interface is available
+}
+
+// Picks the interface named in the configuration, or else the first interface that is up and
+// has an address nodes can use. Falls back to loopback so a single host works without any network.
+func selectInterface() (net.Interface, error) {
+	if config.NETWORK_INTERFACE != "" {
+		iface, err := net.InterfaceByName(config.NETWORK_INTERFACE)
+		if err != nil {
+			return net.Interface{}, fmt.Errorf("configured interface %s: %v", config.NETWORK_INTERFACE, err)
+		}
+		return *iface, nil
+	}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return net.Interface{}, err
+	}
+	var loopback *net.Interface
+	for i, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			if loopback == nil {
+				loopback = &interfaces[i]
+			}
+			continue
+		}
+		if iface.Flags&net.FlagUp != 0 && nodeAddress(iface) != nil {
+			return iface, nil
+		}
+	}
+	if loopback != nil {
+		return *loopback, nil
+	}
+	return net.Interface{}, errors.New("no usable network interface")
+}
+
+// First IPv4 or IPv6 network of the interface. Link-local addresses are left out, as IPv6 ones only
+// work together with the interface name.
+func ipNetwork(iface net.Interface, ipv6 bool) *net.IPNet {
+	addresses, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, address := range addresses {
+		if ipNet, ok := address.(*net.IPNet); ok && (ipNet.IP.To4() == nil) == ipv6 && !ipNet.IP.IsLinkLocalUnicast() {
+			return ipNet
+		}
+	}
+	return nil
+}
+
+func ipv4Network(iface net.Interface) *net.IPNet {
+	return ipNetwork(iface, false)
+}
+
+// Address the node accepts connections on. IPv4 is preferred unless the multicast transport uses an
+// IPv6 group, and the other family is used when the interface only has that one.
+func nodeAddress(iface net.Interface) net.IP {
+	group := net.ParseIP(config.DISCOVERY_GROUP)
+	preferIPv6 := config.DISCOVERY_TRANSPORT == "multicast" && group != nil && group.To4() == nil
+	for _, ipv6 := range []bool{preferIPv6, !preferIPv6} {
+		if ipNet := ipNetwork(iface, ipv6); ipNet != nil {
+			return ipNet.IP
+		}
+	}
+	return nil
+}
+
+func isAvailable(iface net.Interface) bool {
+	current, err := net.InterfaceByName(iface.Name)
+	if err != nil {
+		return false
+	}
+	return current.Flags&net.FlagUp != 0 && nodeAddress(*current) != nil
+}
+
+// Starts polling the link state of the selected interface. Safe to call more than once.
+func startInterfaceMonitor() error {
+	link.mutex.Lock()
+	defer link.mutex.Unlock()
+	if link.started {
+		return nil
+	}
+	iface, err := selectInterface()
+	if err != nil {
+		return err
+	}
+	log.Info("Using network interface", "interface", iface.Name)
+	link.iface = iface
+	link.up = make(chan bool)
+	link.started = true
+	setAvailable(isAvailable(iface))
+	go monitorInterface()
+	return nil
+}
+
+// Must be called with link.mutex held
+func setAvailable(available bool) {
+	if available == link.available {
+		return
+	}
+	link.available = available
+	if available {
+		log.Info("Network interface is up", "interface", link.iface.Name)
+		close(link.up)
+	} else {
+		log.Warn("Network interface is down", "interface", link.iface.Name)
+		link.up = make(chan bool)
+	}
+}
+
+func monitorInterface() {
+	for {
+		time.Sleep(config.INTERFACE_POLL_PERIOD)
+		link.mutex.Lock()
+		setAvailable(isAvailable(link.iface))
+		link.mutex.Unlock()
+	}
+}
+
+func interfaceAvailable() bool {
+	link.mutex.Lock()
+	defer link.mutex.Unlock()
+	return link.available
+}
+
+// Blocks until the selected interface is available
+func waitForInterface() {
+	link.mutex.Lock()
+	up := link.up
+	link.mutex.Unlock()
+	<-up
+}
+
+// Address of this host on the selected interface
+func GetIPAddress() (string, error) {
+	if err := startInterfaceMonitor(); err != nil {
+		return "", err
+	}
+	link.mutex.Lock()
+	defer link.mutex.Unlock()
+	current, err := net.InterfaceByName(link.iface.Name)
+	if err != nil {
+		return "", err
+	}
+	ip := nodeAddress(*current)
+	if ip == nil {
+		return "", fmt.Errorf("interface %s has no usable IP address", link.iface.Name)
+	}
+	return ip.String(), nil
+}
+
+// Directed broadcast address of the selected interface, which also works on loopback. Broadcast only
+// exists in IPv4, so IPv6 networks need the multicast or static transport.
+func broadcastAddress() string {
+	link.mutex.Lock()
+	defer link.mutex.Unlock()
+	ipNet := ipv4Network(link.iface)
+	if ipNet == nil {
+		return BROADCAST_ADDRESS
+	}
+	ip := ipNet.IP.To4()
+	mask := ipNet.Mask[len(ipNet.Mask)-net.IPv4len:]
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range broadcast {
+		broadcast[i] = ip[i] | ^mask[i]
+	}
+	return broadcast.String()
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"project-group-81/config"
 	"project-group-81/elevator"
+	"project-group-81/journal"
+	"project-group-81/metrics"
 	"project-group-81/types"
-	"reflect"
+	"project-group-81/webhook"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,15 +20,17 @@ import (
 var mutex sync.Mutex
 
 func (n *NetworkNode) broadcastMaster() {
-	fmt.Printf("Master broadcasting socket to new nodes.\n")
-	broadcastAddress := fmt.Sprintf("%s:%s", BROADCAST_ADDRESS, POLISH_POPE_DEATH_PORT)
-	localAddress := fmt.Sprintf(":%s", n.getOwnProcess().Socket.Port)
+	log.Info("Master announcing socket to new nodes", "discovery", config.DISCOVERY_TRANSPORT)
+	beacon := n.beacon()
 	for {
-		connection, err := reuseable.Dial(BROADCAST_NETWORK, localAddress, broadcastAddress)
+		announcer, err := newAnnouncer()
 		if err == nil {
-			connection.Write([]byte(connection.LocalAddr().String()))
+			announcer.announce(beacon)
+			announcer.close()
 		} else if !interfaceAvailable() {
 			return
+		} else {
+			log.Warn("Failed to announce master", "err", err)
 		}
 		time.Sleep(MASTER_BROADCAST_PERIOD)
 	}
@@ -33,31 +39,41 @@ func (n *NetworkNode) broadcastMaster() {
 func (n *NetworkNode) listenForConnections(
 	slaveConnections map[int]net.Conn,
 	consistentSlaves map[int]bool,
-	slaveMessageChan chan<- []byte) {
+	slaveMessageChan chan<- []byte,
+	detector *failureDetector) {
 	mainSocket := n.getOwnProcess().Socket.String()
 	listener, _ := reuseable.Listen(NETWORK, mainSocket)
 	for {
 		if !interfaceAvailable() {
 			return
 		}
-		fmt.Printf("Master is listening for new slave on: %s\n", mainSocket)
+		log.Debug("Master is listening for new slave", "socket", mainSocket)
 		conn, err := listener.Accept()
 		if err != nil {
-			fmt.Printf("Error accepting connection in listenForConnections: %v", err)
+			log.Warn("Failed to accept connection from slave", "err", err)
 			if !interfaceAvailable() {
 				return
 			}
+			continue
 		}
-		fmt.Printf("Slave connected: %s\n", conn.RemoteAddr().String())
+		log.Info("Slave connected", "peer", conn.RemoteAddr().String())
 
-		// Read elevator socket from slave
-		buf := make([]byte, MESSAGE_BUFFER_LENGTH)
-		length, err := conn.Read(buf)
+		// Read elevator socket from slave, or the identity of an observer
+		packets := newPacketReader(conn.Read)
+		conn.SetReadDeadline(time.Now().Add(HANDSHAKE_TIMEOUT))
+		message, err := packets.next()
 		if err != nil {
-			fmt.Printf("Error reading elevator socket from slave.\n")
+			log.Warn("Failed to read elevator socket from slave", "peer", conn.RemoteAddr().String(), "err", err)
+			conn.Close()
+			continue
 		}
-		elevatorSocket := FromString(string(buf[:length]))
+		conn.SetReadDeadline(time.Time{})
+		handshake := string(message)
+		observer := strings.HasPrefix(handshake, OBSERVER_HANDSHAKE)
+		elevatorSocket := FromString(strings.TrimPrefix(handshake, OBSERVER_HANDSHAKE))
 		if !elevatorSocket.Valid() {
+			log.Warn("Invalid handshake from slave", "peer", conn.RemoteAddr().String(), "handshake", handshake)
+			conn.Close()
 			continue
 		}
 
@@ -76,60 +92,118 @@ func (n *NetworkNode) listenForConnections(
 			}
 		}
 		if !found {
-			id = len(n.Processes)
+			id = n.nextProcessId()
 			slaveProcess := Process{
 				Id:             id,
 				Socket:         FromString(conn.RemoteAddr().(*net.TCPAddr).String()),
 				Active:         true,
 				ElevatorSocket: elevatorSocket,
-				Elevator:       elevator.DefaultElevator()}
+				Elevator:       elevator.DefaultElevator(),
+				Observer:       observer}
 			n.Processes = append(n.Processes, slaveProcess)
 			slaveConnections[slaveProcess.Id] = conn
 			consistentSlaves[slaveProcess.Id] = false
 		}
 
-		message, err := json.Marshal(n.Processes)
+		message, err = json.Marshal(n.Processes)
 		if err != nil {
-			fmt.Printf("Error marshalling processes: %#v\n", err)
+			log.Error("Failed to marshal processes", "err", err)
 		}
 		mutex.Unlock()
+		if observer {
+			log.Info("Observer joined", "peer", id)
+		}
 
-		_, err = conn.Write(message)
+		err = sendEncoded(conn, message)
 		if err != nil {
-			fmt.Printf("Error writing slaveProcess to slave: %#v\n", err)
+			log.Warn("Failed to send processes to slave", "peer", id, "err", err)
 		}
 
-		go listenToSlave(id, conn, slaveMessageChan)
+		detector.heartbeat(id)
+		done := make(chan bool)
+		go sendHeartbeats(conn, done)
+		go listenToSlave(id, packets, slaveMessageChan, detector, done)
 	}
 }
 
-func listenToSlave(id int, slaveConn net.Conn, slaveMessageChan chan<- []byte) {
-	buf := make([]byte, MESSAGE_BUFFER_LENGTH)
+// Passes the messages of the slave on until the connection fails, then closes done
+func listenToSlave(id int, packets *packetReader, slaveMessageChan chan<- []byte, detector *failureDetector, done chan<- bool) {
+	defer close(done)
 	for {
-		length, err := slaveConn.Read(buf)
+		message, err := packets.next()
 		if err != nil {
-			fmt.Printf("Failed to receive from slave.\n")
+			log.Warn("Failed to receive from slave", "peer", id, "err", err)
 			return
 		}
-		for _, message := range decode(buf[:length]) {
-			go func(message []byte) {
-				slaveMessageChan <- append([]byte{byte(id)}, message...)
-			}(message)
-		}
+		detector.heartbeat(id) // Any message from the slave proves it is alive
+		go func(message []byte) {
+			slaveMessageChan <- append([]byte{byte(id)}, message...)
+		}(message)
 	}
 }
 
 func (n *NetworkNode) deleteNode(idToDelete int, slaveConnections map[int]net.Conn) {
 	mutex.Lock()
-	defer mutex.Unlock()
+	lost, observer := false, false
 	for i, p := range n.Processes {
 		if p.Id == idToDelete {
-			p.Active = false
-			n.Processes[i] = p
+			lost, observer = p.Active, p.Observer
+			if p.Observer { // Observers take a new identity when they join again, so they are not kept
+				n.Processes = append(n.Processes[:i], n.Processes[i+1:]...)
+			} else {
+				p.Active = false
+				n.Processes[i] = p
+			}
 			break
 		}
 	}
 	delete(slaveConnections, idToDelete)
+	mutex.Unlock()
+	// Notified without the lock, as the webhook outbox is saved to disk
+	if lost {
+		webhook.Notify(webhook.NodeInactive, "Lost contact with node", "peer", idToDelete, "observer", observer)
+	}
+}
+
+// Lowest id above those of all known processes, as lost observers leave gaps
+func (n *NetworkNode) nextProcessId() int {
+	id := 0
+	for _, p := range n.Processes {
+		if p.Id >= id {
+			id = p.Id + 1
+		}
+	}
+	return id
+}
+
+// Stores the reported state of an elevator and reassigns orders if that is called for
+func (n *NetworkNode) updateElevatorState(
+	id int,
+	e elevator.Elevator,
+	slaveConnections map[int]net.Conn,
+	slaveMessageChan chan []byte) {
+
+	mutex.Lock()
+	var previous elevator.Elevator
+	for i, p := range n.Processes {
+		if p.Id == id {
+			previous = p.Elevator
+			p.Elevator = e
+			n.Processes[i] = p
+		}
+	}
+	mutex.Unlock()
+
+	if previous.AcceptsHallOrders() && !e.AcceptsHallOrders() {
+		log.Warn("Elevator stopped accepting hall orders", "peer", id, "reasons", e.HallOrderBlockers())
+		n.reassignOrders()
+		n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+	} else if previous.State != types.Standby && e.State == types.Standby {
+		// An idle elevator may take orders from busier ones
+		if n.reoptimiseOrders() {
+			n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+		}
+	}
 }
 
 func (n *NetworkNode) sendToSlaves(
@@ -138,33 +212,74 @@ func (n *NetworkNode) sendToSlaves(
 	slaveConnections map[int]net.Conn,
 	slaveMessageChan chan []byte) {
 	toSend := append([]byte{flag}, message...)
+	lostSlave := false
 	for id, conn := range slaveConnections {
 		conn.SetWriteDeadline(time.Now().Add(SLAVE_WRITE_TIMEOUT))
 		err := sendEncoded(conn, toSend)
 		if err != nil {
-			fmt.Printf("Failed to connect to slave %d.\n", id)
+			log.Warn("Failed to send to slave", "peer", id, "err", err)
 			n.deleteNode(id, slaveConnections)
-			n.reassignOrders()
-			message, err := json.Marshal(n.AssignedOrders)
-			if err != nil {
-				fmt.Print("Warning: Failed to marshal AssignedOrders\n")
-			}
-			n.sendToSlaves(ASSIGNED_ORDERS_FLAG, message, slaveConnections, slaveMessageChan)
+			lostSlave = true
 		}
 	}
 	// Also send to local elevator
 	go func(toSend []byte, Id int) {
 		slaveMessageChan <- append([]byte{byte(Id)}, toSend...)
 	}(toSend, n.Id)
+	if lostSlave {
+		n.reassignOrders()
+		n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+	}
+}
+
+// Sends a full snapshot of the assigned orders to a single slave
+func (n *NetworkNode) sendSnapshot(id int, slaveConnections map[int]net.Conn) {
+	conn, found := slaveConnections[id]
+	if !found {
+		return
+	}
+	message, err := json.Marshal(OrdersSnapshot{n.Epoch, n.Version, n.AssignedOrders})
+	if err != nil {
+		log.Error("Failed to marshal assigned orders snapshot", "err", err)
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(SLAVE_WRITE_TIMEOUT))
+	err = sendEncoded(conn, append([]byte{ASSIGNED_ORDERS_FLAG}, message...))
+	if err != nil {
+		log.Warn("Failed to send snapshot to slave", "peer", id, "err", err)
+		conn.Close() // The failure detector takes it from here
+	}
+}
+
+// Sends the changes made to AssignedOrders since the last broadcast to every slave
+func (n *NetworkNode) broadcastAssignedOrders(slaveConnections map[int]net.Conn, slaveMessageChan chan []byte) {
+	delta := ordersDelta(n.publishedOrders, n.AssignedOrders, n.Version)
+	if len(delta.Added) == 0 && len(delta.Removed) == 0 {
+		return
+	}
+	message, err := json.Marshal(delta)
+	if err != nil {
+		log.Error("Failed to marshal assigned orders delta", "err", err)
+		return
+	}
+	n.Version = delta.Version
+	n.publishedOrders = append([]AssignedOrder{}, n.AssignedOrders...)
+	if n.roundStarted.IsZero() {
+		n.roundStarted = time.Now()
+	}
+	n.sendToSlaves(ORDERS_DELTA_FLAG, message, slaveConnections, slaveMessageChan)
 }
 
 // Receives messages from the network and forwards them to the right channel
 func (n *NetworkNode) forwardMessages(
 	slaveMessageChan <-chan []byte,
-	assignedOrderDumpChan chan<- []byte,
+	digestChan chan<- slaveDigest,
+	snapshotRequestChan chan<- int,
 	newOrderChan,
 	finishedOrderChan chan<- types.Order,
-	nodeStateChan chan<- []byte) {
+	nodeStateChan chan<- []byte,
+	reassignRequestChan chan<- int,
+	slaveCarRequestChan chan<- CarRequest) {
 
 	for {
 		slaveMessage := <-slaveMessageChan
@@ -175,14 +290,31 @@ func (n *NetworkNode) forwardMessages(
 		flag := slaveMessage[1]
 		trimmedMessage := slaveMessage[2:]
 		switch flag {
-		case ASSIGNED_ORDERS_FLAG:
-			assignedOrderDumpChan <- append([]byte{byte(slaveId)}, trimmedMessage...)
+		case ORDERS_DELTA_FLAG: // Echo of a delta sent to the local elevator
+			var delta OrdersDelta
+			err := json.Unmarshal(trimmedMessage, &delta)
+			if err == nil {
+				digestChan <- slaveDigest{slaveId, OrdersDigest{delta.Version, delta.Hash}}
+			}
+		case ORDERS_DIGEST_FLAG:
+			var digest OrdersDigest
+			err := json.Unmarshal(trimmedMessage, &digest)
+			if err == nil {
+				digestChan <- slaveDigest{slaveId, digest}
+			}
+		case SNAPSHOT_REQUEST_FLAG:
+			snapshotRequestChan <- slaveId
 		case NEW_ORDER_FLAG:
 			var order types.Order
 			err := json.Unmarshal(trimmedMessage, &order)
-			if err == nil {
-				newOrderChan <- order
+			if err != nil {
+				break
 			}
+			if order.C == types.Car && n.isObserver(slaveId) {
+				log.Warn("Ignoring cab order from observer", "peer", slaveId, "order", order)
+				break
+			}
+			newOrderChan <- order
 		case FINISHED_ORDER_FLAG:
 			var order types.Order
 			err := json.Unmarshal(trimmedMessage, &order)
@@ -191,6 +323,14 @@ func (n *NetworkNode) forwardMessages(
 			}
 		case ELEVATOR_STATE_FLAG:
 			nodeStateChan <- append([]byte{byte(slaveId)}, trimmedMessage...)
+		case REASSIGN_REQUEST_FLAG:
+			reassignRequestChan <- slaveId
+		case CAR_REQUEST_FLAG:
+			var request CarRequest
+			err := json.Unmarshal(trimmedMessage, &request)
+			if err == nil {
+				slaveCarRequestChan <- request
+			}
 		}
 	}
 }
@@ -200,18 +340,56 @@ func (n *NetworkNode) masterRun(
 	finishedOrderChan chan types.Order,
 	lightOnChan, lightOffChan chan<- types.Order,
 	stateChan chan elevator.Elevator,
-	assignedOrderChan chan<- types.Order) {
+	assignedOrderChan,
+	revokedOrderChan chan<- types.Order,
+	connectedChan chan<- bool,
+	parkChan chan<- types.Floor,
+	statusRequestChan <-chan chan Status,
+	reassignChan <-chan bool,
+	carRequestChan <-chan CarRequest,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) {
 
 	infoTimer := time.NewTimer(MASTER_INFO_PERIOD)
 	slaveConnections := make(map[int]net.Conn)
-	assignedOrderDumpChan := make(chan []byte)
+	digestChan := make(chan slaveDigest)
+	snapshotRequestChan := make(chan int)
 	slaveMessageChan := make(chan []byte)
 	nodeStateChan := make(chan []byte)
+	reassignRequestChan := make(chan int)
+	slaveCarRequestChan := make(chan CarRequest)
 	consistentSlaves := make(map[int]bool)
+	detector := newFailureDetector()
+	reoptimiseTicker := time.NewTicker(config.REOPTIMISE_PERIOD)
+	parkingTicker := time.NewTicker(PARKING_CHECK_PERIOD)
+	hallWaitTicker := time.NewTicker(HALL_WAIT_CHECK_PERIOD)
+	n.idleSince = make(map[int]time.Time)
+	n.parkingFloors = make(map[int]types.Floor)
+	if n.demand == nil {
+		n.demand = loadDemandHistory()
+	}
+	n.hallCallTimes = make(map[types.Order]time.Time)
+	// Orders inherited from the previous master are timed from the takeover, as their earlier wait is not known
+	for _, assigned := range n.AssignedOrders {
+		if assigned.Order.C != types.Car {
+			n.hallCallTimes[assigned.Order] = time.Now()
+		}
+	}
+	n.hallWaitAlerts = make(types.OrderSet)
+	n.lastReassigned = make(map[types.Order]time.Time) // Orders may have finished while another node was master
+	n.roundStarted = time.Time{}
+	n.Epoch++
+	n.announceRole("master")
+	metrics.MasterElections.Inc()
+	if n.Epoch > 1 {
+		webhook.Notify(webhook.MasterFailover, "Took over as master", "epoch", n.Epoch)
+	}
+	connected := false                           // Whether another elevator has joined, see hasActiveSlaves
 	n.PreviousAssignedOrders = []AssignedOrder{} // Pretend that all assigned orders are new
+	n.publishedOrders = []AssignedOrder{}        // Slaves joining this master will ask for a snapshot anyway
 	go n.broadcastMaster()
-	go n.listenForConnections(slaveConnections, consistentSlaves, slaveMessageChan)
-	go n.forwardMessages(slaveMessageChan, assignedOrderDumpChan, newOrderChan, finishedOrderChan, nodeStateChan)
+	go n.listenForConnections(slaveConnections, consistentSlaves, slaveMessageChan, detector)
+	go n.forwardMessages(slaveMessageChan, digestChan, snapshotRequestChan, newOrderChan, finishedOrderChan, nodeStateChan, reassignRequestChan, slaveCarRequestChan)
 
 	mutex.Lock()
 	for i, process := range n.Processes {
@@ -222,113 +400,176 @@ func (n *NetworkNode) masterRun(
 	}
 	mutex.Unlock()
 	n.reassignOrders()
-	message, err := json.Marshal(n.AssignedOrders)
-	if err != nil {
-		fmt.Print("Warning: Failed to marshal AssignedOrders\n")
-	}
-	n.sendToSlaves(ASSIGNED_ORDERS_FLAG, message, slaveConnections, slaveMessageChan)
+	n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
 	for {
 		if !interfaceAvailable() {
-			n.ReinitializeNode(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+			reoptimiseTicker.Stop()
+			parkingTicker.Stop()
+			hallWaitTicker.Stop()
+			detector.stop()
+			n.ReinitializeNode(-1, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
+		}
+		if active := n.hasActiveSlaves(); active != connected {
+			connected = active
+			connectedChan <- connected
 		}
 		select {
-		case assignedOrderDump := <-assignedOrderDumpChan:
-			slaveId := int(assignedOrderDump[0])
-			assignedOrderDump = assignedOrderDump[1:]
-			toCompare, err := json.Marshal(n.AssignedOrders)
-			if err != nil {
-				fmt.Print("Warning: Failed to marshal AssignedOrders\n")
-			}
-			if reflect.DeepEqual(assignedOrderDump, toCompare) {
+		case slaveDigest := <-digestChan:
+			slaveId := slaveDigest.Id
+			consistent, needsSnapshot := n.checkDigest(slaveDigest.Digest)
+			if consistent {
 				mutex.Lock()
 				consistentSlaves[slaveId] = true
 				if activeSlavesConsistent(n.Processes, consistentSlaves) {
-					fmt.Printf("Active slaves are consistent. Sending light confirmation message.\n")
+					log.Debug("Active slaves are consistent, sending light confirmation", "version", n.Version)
 					for consistentSlave := range consistentSlaves {
 						consistentSlaves[consistentSlave] = false
 					}
 					// Sending consistent state confirmation
 					confirmationMessage, err := json.Marshal(true)
 					if err != nil {
-						fmt.Print("Warning: Failed to marshal confirmation message\n")
+						log.Error("Failed to marshal confirmation message", "err", err)
 					}
 					n.sendToSlaves(CONFIRMATION_FLAG, confirmationMessage, slaveConnections, slaveMessageChan)
-					// Updating lights for own local elevator
-					diff := recentlyAssignedOrders(n.AssignedOrders, n.PreviousAssignedOrders)
-					for _, order := range diff {
-						lightOnChan <- order.Order
-						if order.Id == n.Id {
-							assignedOrderChan <- order.Order
-						}
-					}
-					diff = recentlyAssignedOrders(n.PreviousAssignedOrders, n.AssignedOrders)
-					for _, order := range diff {
-						lightOffChan <- order.Order
+					if !n.roundStarted.IsZero() {
+						metrics.ConsistencyRoundTime.Observe(time.Since(n.roundStarted).Seconds())
+						n.roundStarted = time.Time{}
 					}
-
-					n.PreviousAssignedOrders = append([]AssignedOrder{}, n.AssignedOrders...)
+					// Updating lights for own local elevator
+					n.applyConfirmedOrders(lightOnChan, lightOffChan, assignedOrderChan, revokedOrderChan)
 				}
 				mutex.Unlock()
-			} else {
-				fmt.Printf("Received inconsistent assigned orders from node %d. Resending to everyone.\n", slaveId)
-				message, err := json.Marshal(n.AssignedOrders)
-				if err != nil {
-					fmt.Print("Warning: Failed to marshal AssignedOrders\n")
-				}
-				n.sendToSlaves(ASSIGNED_ORDERS_FLAG, message, slaveConnections, slaveMessageChan)
+			} else if needsSnapshot {
+				log.Warn("Received inconsistent assigned orders, sending snapshot", "peer", slaveId, "version", slaveDigest.Digest.Version)
+				n.sendSnapshot(slaveId, slaveConnections)
+			}
+		case slaveId := <-snapshotRequestChan:
+			n.sendSnapshot(slaveId, slaveConnections)
+		case event := <-detector.events:
+			if event.Alive {
+				log.Info("Slave is alive again", "peer", event.Id)
+				break
+			}
+			detector.forget(event.Id)
+			if conn, found := slaveConnections[event.Id]; found {
+				log.Warn("Slave suspected dead without heartbeat", "peer", event.Id, "timeout", config.SUSPECT_TIMEOUT)
+				conn.Close() // Forces the slave to search for a master again if it is still running
+				n.deleteNode(event.Id, slaveConnections)
+				n.reassignOrders()
+				n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
 			}
 		case <-infoTimer.C:
+			mutex.Lock()
+			n.updateProcessMetrics(n.Id)
+			mutex.Unlock()
 			processesBlob, err := json.Marshal(n.Processes)
 			if err != nil {
-				fmt.Print("Warning: Failed to marshal Processes\n")
+				log.Error("Failed to marshal processes", "err", err)
 			}
 			n.sendToSlaves(PROCESSES_FLAG, processesBlob, slaveConnections, slaveMessageChan)
 			infoTimer.Reset(MASTER_INFO_PERIOD)
 		case order := <-newOrderChan:
 			if !contains(n.AssignedOrders, order) {
-				assignedOrder := Assign(order, n.Processes)
+				n.demand.record(order, time.Now())
+				metrics.OrdersCreated.Inc(order.C.String())
+				n.hallCallTimes[order] = time.Now()
+				assignedOrder := n.assign(order, "new order")
+				journal.RecordAssignment(journal.Assigned, order, -1, assignedOrder.Id)
 				n.AssignedOrders = append(n.AssignedOrders, assignedOrder)
-				message, err := json.Marshal(n.AssignedOrders)
-				if err != nil {
-					fmt.Print("Warning: Failed to marshal AssignedOrders\n")
-				}
-				n.sendToSlaves(ASSIGNED_ORDERS_FLAG, message, slaveConnections, slaveMessageChan)
+				n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
 			}
 		case order := <-finishedOrderChan:
 			for i, assignedOrder := range n.AssignedOrders {
 				if assignedOrder.Order == order {
 					n.AssignedOrders = append(n.AssignedOrders[:i], n.AssignedOrders[i+1:]...)
+					metrics.OrdersFinished.Inc(order.C.String())
 				}
 			}
-			lightOffChan <- order
-			message, err := json.Marshal(n.AssignedOrders)
-			if err != nil {
-				fmt.Print("Warning: Failed to marshal AssignedOrders\n")
+			if created, found := n.hallCallTimes[order]; found {
+				metrics.HallCallWait.Observe(time.Since(created).Seconds(), order.C.String())
+				delete(n.hallCallTimes, order)
+				n.hallWaitAlerts.Remove(order)
 			}
-			n.sendToSlaves(ASSIGNED_ORDERS_FLAG, message, slaveConnections, slaveMessageChan)
-		case elevator := <-stateChan: // New state from local elevator
-			for _, p := range n.Processes {
-				if p.Id == n.Id {
-					p.Elevator = elevator
-					n.Processes[n.Id] = p
-				}
+			delete(n.lastReassigned, order)
+			if !buttonInUse(n.AssignedOrders, order.Button()) {
+				lightOffChan <- order
 			}
+			n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+		case elevator := <-stateChan: // New state from local elevator
+			n.updateElevatorState(n.Id, elevator, slaveConnections, slaveMessageChan)
 		case newNodeStateBlob := <-nodeStateChan: // New state for other elevator
 			newNodeStateId := int(newNodeStateBlob[0])
 			var elevator elevator.Elevator
 			err := json.Unmarshal(newNodeStateBlob[1:], &elevator)
 			if err != nil {
-				fmt.Printf("Failed to unmarshal new slave state %#v\n", elevator)
+				log.Warn("Failed to unmarshal slave state", "peer", newNodeStateId, "err", err)
 			} else {
-				mutex.Lock()
-				for i, p := range n.Processes {
-					if p.Id == newNodeStateId {
-						p.Elevator = elevator
-						n.Processes[i] = p
-					}
-				}
-				mutex.Unlock()
+				n.updateElevatorState(newNodeStateId, elevator, slaveConnections, slaveMessageChan)
+			}
+		case <-hallWaitTicker.C:
+			n.alertLongHallWaits(time.Now())
+		case <-parkingTicker.C:
+			targets := n.parkIdleElevators(time.Now())
+			if len(targets) == 0 {
+				break
+			}
+			message, err := json.Marshal(targets)
+			if err != nil {
+				log.Error("Failed to marshal parking floors", "err", err)
+				break
+			}
+			log.Info("Parking idle elevators", "targets", targets)
+			n.sendToSlaves(PARK_FLAG, message, slaveConnections, slaveMessageChan)
+			if floor, found := targets[n.Id]; found {
+				parkChan <- floor
+			}
+		case <-reoptimiseTicker.C:
+			if n.reoptimiseOrders() {
+				n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+			}
+		case reply := <-statusRequestChan:
+			reply <- n.status("master", n.Id)
+		case <-reassignChan:
+			n.redistributeOrders("reassignment requested")
+			n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+		case slaveId := <-reassignRequestChan:
+			n.redistributeOrders(fmt.Sprintf("reassignment requested by node %d", slaveId))
+			n.broadcastAssignedOrders(slaveConnections, slaveMessageChan)
+		case request := <-carRequestChan:
+			n.routeCarRequest(request, slaveConnections, remoteButtonChan, serviceChan)
+		case request := <-slaveCarRequestChan:
+			n.routeCarRequest(request, slaveConnections, remoteButtonChan, serviceChan)
+		}
+	}
+}
+
+// Whether an elevator other than the master's own is connected. A master on its own, such as one cut
+// off by a partition, handles hall calls by OFFLINE_HALL_POLICY like a node without a master.
+func (n *NetworkNode) hasActiveSlaves() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, process := range n.Processes {
+		if process.Id != n.Id && process.Active && !process.Observer {
+			return true
+		}
+	}
+	return false
+}
+
+// Sends a webhook event for every hall call that has waited longer than WEBHOOK_HALL_WAIT, once per call
+func (n *NetworkNode) alertLongHallWaits(now time.Time) {
+	for order, created := range n.hallCallTimes {
+		if now.Sub(created) < config.WEBHOOK_HALL_WAIT || n.hallWaitAlerts.Contains(order) {
+			continue
+		}
+		n.hallWaitAlerts.Insert(order)
+		assignee := -1
+		for _, assigned := range n.AssignedOrders {
+			if assigned.Order == order {
+				assignee = assigned.Id
 			}
 		}
+		log.Warn("Hall call is waiting too long", "order", order, "waited", now.Sub(created).Round(time.Second), "peer", assignee)
+		webhook.Notify(webhook.HallCallWaiting, "Hall call is waiting too long", "order", order.String(), "waited", now.Sub(created).Round(time.Second).String(), "assignee", assignee)
 	}
 }
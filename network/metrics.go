@@ -0,0 +1,65 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"project-group-81/metrics"
+)
+
+var flagNames = map[byte]string{
+	ASSIGNED_ORDERS_FLAG:  "assigned_orders",
+	PROCESSES_FLAG:        "processes",
+	CONFIRMATION_FLAG:     "confirmation",
+	NEW_ORDER_FLAG:        "new_order",
+	FINISHED_ORDER_FLAG:   "finished_order",
+	ELEVATOR_STATE_FLAG:   "elevator_state",
+	HEARTBEAT_FLAG:        "heartbeat",
+	ORDERS_DELTA_FLAG:     "orders_delta",
+	ORDERS_DIGEST_FLAG:    "orders_digest",
+	SNAPSHOT_REQUEST_FLAG: "snapshot_request",
+	PARK_FLAG:             "park",
+	REASSIGN_REQUEST_FLAG: "reassign_request",
+	CAR_REQUEST_FLAG:      "car_request",
+}
+
+func flagName(message []byte) string {
+	if len(message) == 0 {
+		return "empty"
+	} else if name, found := flagNames[message[0]]; found {
+		return name
+	}
+	return fmt.Sprint(message[0])
+}
+
+// Counts a message and the bytes it took on the wire
+func countSent(message []byte, length int) {
+	metrics.MessagesSent.Inc(flagName(message))
+	metrics.MessageBytesSent.Add(float64(length), flagName(message))
+}
+
+func countReceived(message []byte, length int) {
+	metrics.MessagesReceived.Inc(flagName(message))
+	metrics.MessageBytesReceived.Add(float64(length), flagName(message))
+}
+
+// Publishes the number of active elevator processes and which one is master
+func (n *NetworkNode) updateProcessMetrics(masterId int) {
+	active := 0
+	for _, p := range n.Processes {
+		if p.Active && !p.Observer {
+			active++
+		}
+	}
+	metrics.ActiveProcesses.Set(float64(active))
+	metrics.MasterId.Set(float64(masterId))
+}
+
+// Id of the process listening on the address, or -1 if there is none
+func (n *NetworkNode) processAt(address net.Addr) int {
+	for _, p := range n.Processes {
+		if p.Socket.String() == address.String() {
+			return p.Id
+		}
+	}
+	return -1
+}
@@ -0,0 +1,161 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"project-group-81/config"
+	"project-group-81/types"
+	"time"
+)
+
+// Joins the network as an observer: a node without an elevator that follows the master's view of the
+// network and passes hall calls from newOrderChan on to it. Observers are never assigned orders and
+// never become master, so when the master is lost they search for the next one until they find it.
+func RunObserver(newOrderChan <-chan types.Order, statusRequestChan <-chan chan Status, carRequestChan <-chan CarRequest) {
+	if err := startInterfaceMonitor(); err != nil {
+		log.Error("Failed to find a network interface", "err", err)
+		return
+	}
+	ipAddress, err := GetIPAddress()
+	if err != nil {
+		log.Error("Failed to find IP address", "err", err)
+		return
+	}
+	freePort, _ := getFreePort()
+	identity := Socket{ipAddress, fmt.Sprint(freePort)} // Lets the master give the observer its old id when it joins again
+	n := NetworkNode{
+		Id:                     -1,
+		AssignedOrders:         []AssignedOrder{},
+		PreviousAssignedOrders: []AssignedOrder{},
+		Processes:              []Process{}}
+
+	for {
+		n.announceRole("searching")
+		waitForInterface()
+		beacon, err := initialSearchForMaster(n.Epoch)
+		if err != nil {
+			// The whole network may have restarted and counts epochs from the start again
+			log.Info("No master found yet, searching again for a master of any epoch", "epoch", n.Epoch)
+			n.Epoch = 0
+			continue
+		}
+		conn, packets, err := n.joinAsObserver(beacon, identity)
+		if err != nil {
+			log.Warn("Failed to join master as observer", "peer", beacon.Endpoint, "err", err)
+			time.Sleep(MASTER_BROADCAST_PERIOD)
+			continue
+		}
+		n.observerRun(conn, packets, newOrderChan, statusRequestChan, carRequestChan)
+	}
+}
+
+func (n *NetworkNode) joinAsObserver(beacon Beacon, identity Socket) (net.Conn, *packetReader, error) {
+	conn, err := net.DialTimeout(NETWORK, beacon.Endpoint, MASTER_RESPONSE_TIMEOUT)
+	if err != nil {
+		return nil, nil, err
+	}
+	packets, err := n.join(conn, OBSERVER_HANDSHAKE+identity.String(), identity)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	n.Epoch = beacon.Epoch
+	return conn, packets, nil
+}
+
+// Follows the master until it is lost. Unlike slaves, observers send no digests, so the master never
+// waits for them before confirming orders.
+func (n *NetworkNode) observerRun(
+	masterConn net.Conn,
+	masterPackets *packetReader,
+	newOrderChan <-chan types.Order,
+	statusRequestChan <-chan chan Status,
+	carRequestChan <-chan CarRequest) {
+
+	slavesConsistentChan := make(chan bool)
+	masterUnreachableChan := make(chan bool, 1) // Buffered so listenToMaster can exit after the observer has moved on
+	snapshotChan := make(chan OrdersSnapshot)
+	deltaChan := make(chan OrdersDelta)
+	processesChan := make(chan []Process)
+	parkingChan := make(chan map[int]types.Floor)
+	masterCarRequestChan := make(chan CarRequest)
+	detector := newFailureDetector()
+	done := make(chan bool) // Stops the goroutines serving the connection to the master
+	defer func() {
+		close(done)
+		detector.stop()
+		masterConn.Close()
+	}()
+
+	detector.heartbeat(MASTER_PEER_ID)
+	n.Version = 0 // Unknown until the master has sent a snapshot
+	if err := patientWrite(masterConn, []byte{SNAPSHOT_REQUEST_FLAG}, MASTER_RESPONSE_TIMEOUT); err != nil {
+		log.Warn("Searching for master again after failing to request snapshot", "err", err)
+		return
+	}
+	go listenToMaster(masterPackets, snapshotChan, deltaChan, slavesConsistentChan, processesChan, parkingChan, masterCarRequestChan, masterUnreachableChan, detector, done)
+	go sendHeartbeats(masterConn, done)
+
+	n.announceRole("observer")
+	log.Info("Running observer", "peer", masterConn.RemoteAddr().String())
+	for {
+		select {
+		case order := <-newOrderChan:
+			if order.C == types.Car {
+				log.Warn("Observers have no car, ignoring cab order", "order", order)
+				break
+			}
+			if contains(n.AssignedOrders, order) {
+				break
+			}
+			toSend, err := json.Marshal(order)
+			if err != nil {
+				log.Error("Failed to marshal new order", "order", order, "err", err)
+				break
+			}
+			if err := patientWrite(masterConn, append([]byte{NEW_ORDER_FLAG}, toSend...), MASTER_RESPONSE_TIMEOUT); err != nil {
+				log.Warn("Searching for master again after failing to send new order", "order", order, "err", err)
+				return
+			}
+		case <-slavesConsistentChan:
+			n.PreviousAssignedOrders = append([]AssignedOrder{}, n.AssignedOrders...)
+		case snapshot := <-snapshotChan:
+			n.AssignedOrders = snapshot.Orders
+			n.Version = snapshot.Version
+			n.Epoch = snapshot.Epoch
+			n.announceRole("observer")
+		case delta := <-deltaChan:
+			orders, err := updateOrders(n.AssignedOrders, n.Version, delta)
+			if err != nil {
+				log.Warn("Requesting snapshot of assigned orders", "err", err)
+				if err := patientWrite(masterConn, []byte{SNAPSHOT_REQUEST_FLAG}, MASTER_RESPONSE_TIMEOUT); err != nil {
+					log.Warn("Searching for master again after failing to request snapshot", "err", err)
+					return
+				}
+				break
+			}
+			n.AssignedOrders = orders
+			n.Version = delta.Version
+		case processes := <-processesChan:
+			n.Processes = processes
+		case <-parkingChan: // Observers have nothing to park
+		case <-masterCarRequestChan: // Nor an elevator to carry out requests
+		case request := <-carRequestChan:
+			if err := sendCarRequest(masterConn, request); err != nil {
+				log.Warn("Searching for master again after failing to send car request", "peer", request.Car, "err", err)
+				return
+			}
+		case reply := <-statusRequestChan:
+			reply <- n.status("observer", n.processAt(masterConn.RemoteAddr()))
+		case event := <-detector.events:
+			if !event.Alive {
+				log.Warn("Master suspected dead without heartbeat, searching again", "timeout", config.SUSPECT_TIMEOUT)
+				return
+			}
+		case <-masterUnreachableChan:
+			log.Warn("Searching for master again because master is unreachable")
+			return
+		}
+	}
+}
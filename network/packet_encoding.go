@@ -5,31 +5,90 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"time"
 )
 
 func sendEncoded(conn net.Conn, buf []byte) error {
 	packet := NetworkPacket{Lenght: len(buf), Content: buf}
 	packetBytes, err := json.Marshal(packet)
 	if err == nil {
-		_, err := conn.Write(append(packetBytes, []byte(PacketDelimiter())...))
+		wire := append(packetBytes, []byte(PacketDelimiter())...)
+		_, err := conn.Write(wire)
+		if err == nil {
+			countSent(buf, len(wire))
+		}
 		return err
 	} else {
-		fmt.Printf("Error in marshalling network packet before sending.\n")
+		log.Error("Failed to marshal network packet", "err", err)
 		return err
 	}
 }
 
+// The delimiter only ends a packet after the closing brace of its JSON object, since the letters of
+// the delimiter may also occur in the base64 encoded content
+func packetEnd() []byte {
+	return append([]byte{'}'}, PacketDelimiter()...)
+}
+
 func decode(message []byte) [][]byte {
 	validPackets := make([][]byte, 0)
-	splittedPackets := bytes.Split(message, []byte(PacketDelimiter()))
-	for _, packet1 := range splittedPackets {
+	for len(message) > 0 {
+		packet1 := message
+		message = nil
+		if end := bytes.Index(packet1, packetEnd()); end >= 0 {
+			packet1, message = packet1[:end+1], packet1[end+len(packetEnd()):]
+		}
 		var packet2 NetworkPacket
 		err := json.Unmarshal(packet1, &packet2)
 		if err == nil {
 			validPackets = append(validPackets, packet2.Content[:packet2.Lenght])
-		} else if len(packet1) != 0 {
-			fmt.Printf("Error in unmarshalling packet: %s\n", packet1)
+			countReceived(packet2.Content[:packet2.Lenght], len(packet1)+len(PacketDelimiter()))
+		} else {
+			log.Warn("Failed to unmarshal packet", "packet", string(packet1), "err", err)
 		}
 	}
 	return validPackets
 }
+
+// Reassembles the packets written by sendEncoded. A read may return part of a packet or several
+// packets, so bytes are kept until the end of a packet has arrived.
+type packetReader struct {
+	read    func([]byte) (int, error)
+	buf     []byte
+	pending []byte
+	packets [][]byte
+}
+
+func newPacketReader(read func([]byte) (int, error)) *packetReader {
+	return &packetReader{read: read, buf: make([]byte, MESSAGE_BUFFER_LENGTH)}
+}
+
+// Reads packets from the master, waiting for it as long as the network interface is down
+func masterPacketReader(conn net.Conn, timeout time.Duration) *packetReader {
+	return newPacketReader(func(buf []byte) (int, error) {
+		return patientRead(conn, buf, timeout)
+	})
+}
+
+// Returns the content of the next packet, reading until one is complete
+func (r *packetReader) next() ([]byte, error) {
+	for len(r.packets) == 0 {
+		if end := bytes.LastIndex(r.pending, packetEnd()); end >= 0 {
+			end += len(packetEnd())
+			r.packets = decode(r.pending[:end])
+			r.pending = append([]byte{}, r.pending[end:]...)
+			continue
+		}
+		if len(r.pending) > MAX_PACKET_LENGTH {
+			return nil, fmt.Errorf("no end of packet within %d bytes", MAX_PACKET_LENGTH)
+		}
+		length, err := r.read(r.buf)
+		if err != nil {
+			return nil, err
+		}
+		r.pending = append(r.pending, r.buf[:length]...)
+	}
+	packet := r.packets[0]
+	r.packets = r.packets[1:]
+	return packet, nil
+}
@@ -0,0 +1,116 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// The bytes sendEncoded writes for the content
+func wire(t *testing.T, content []byte) []byte {
+	t.Helper()
+	blob, err := json.Marshal(NetworkPacket{Lenght: len(content), Content: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(blob, PacketDelimiter()...)
+}
+
+// A reader returning one chunk per read, then EOF
+func chunkReader(chunks [][]byte) func([]byte) (int, error) {
+	return func(buf []byte) (int, error) {
+		if len(chunks) == 0 {
+			return 0, io.EOF
+		}
+		length := copy(buf, chunks[0])
+		if chunks[0] = chunks[0][length:]; len(chunks[0]) == 0 {
+			chunks = chunks[1:]
+		}
+		return length, nil
+	}
+}
+
+// Splits the bytes into chunks of the size
+func split(blob []byte, size int) [][]byte {
+	var chunks [][]byte
+	for len(blob) > size {
+		chunks = append(chunks, blob[:size])
+		blob = blob[size:]
+	}
+	return append(chunks, blob)
+}
+
+func TestPacketReader(t *testing.T) {
+	state := []byte(`{"Floor":2,"Dirn":1}`)
+	withEnd := []byte(`{"a":"}END"}END`) // The delimiter in the content itself
+	base64End := []byte{0, 'C', 'C'}     // Encodes to "AEND"
+	large := bytes.Repeat([]byte{0xa5}, MESSAGE_BUFFER_LENGTH*3)
+	both := append(wire(t, state), wire(t, withEnd)...)
+	if !bytes.Contains(wire(t, base64End), []byte(`"AEND"`)) {
+		t.Fatal("fixture has no delimiter in its base64 content")
+	}
+
+	for _, c := range []struct {
+		name    string
+		chunks  [][]byte
+		packets [][]byte
+	}{
+		{"one read", [][]byte{wire(t, state)}, [][]byte{state}},
+		{"split in bytes", split(wire(t, state), 1), [][]byte{state}},
+		{"split before the delimiter", split(wire(t, state), len(wire(t, state))-2), [][]byte{state}},
+		{"merged into one read", [][]byte{both}, [][]byte{state, withEnd}},
+		{"merged and split", split(both, 7), [][]byte{state, withEnd}},
+		{"delimiter in the content", [][]byte{wire(t, withEnd)}, [][]byte{withEnd}},
+		{"delimiter in the base64 content", split(wire(t, base64End), 3), [][]byte{base64End}},
+		{"larger than a read", [][]byte{wire(t, large)}, [][]byte{large}},
+		{"empty content", [][]byte{wire(t, []byte{})}, [][]byte{{}}},
+	} {
+		reader := newPacketReader(chunkReader(c.chunks))
+		for i, want := range c.packets {
+			got, err := reader.next()
+			if err != nil {
+				t.Errorf("%s: packet %d: %v", c.name, i, err)
+				break
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: packet %d: got %q, want %q", c.name, i, got, want)
+			}
+		}
+		if got, err := reader.next(); err != io.EOF {
+			t.Errorf("%s: got %q and %v after the last packet, want EOF", c.name, got, err)
+		}
+	}
+}
+
+func TestPacketReaderSkipsBrokenPackets(t *testing.T) {
+	state := []byte(`{"Floor":2}`)
+	chunks := [][]byte{[]byte(`{"Lenght":3,"Content":"???"}END`), wire(t, state)}
+	got, err := newPacketReader(chunkReader(chunks)).next()
+	if err != nil || !bytes.Equal(got, state) {
+		t.Errorf("got %q, %v, want %q", got, err, state)
+	}
+}
+
+func TestPacketReaderOversize(t *testing.T) {
+	reads := 0
+	endless := func(buf []byte) (int, error) {
+		reads++
+		if reads*len(buf) > MAX_PACKET_LENGTH*2 {
+			return 0, errors.New("read past the packet limit")
+		}
+		return copy(buf, bytes.Repeat([]byte{'A'}, len(buf))), nil
+	}
+	if _, err := newPacketReader(endless).next(); err == nil || err.Error() == "read past the packet limit" {
+		t.Errorf("got %v, want the packet rejected once it passes %d bytes", err, MAX_PACKET_LENGTH)
+	}
+}
+
+func TestPacketReaderReadError(t *testing.T) {
+	broken := errors.New("connection reset")
+	read := func(buf []byte) (int, error) { return 0, broken }
+	if _, err := newPacketReader(read).next(); err != broken {
+		t.Errorf("got %v, want %v", err, broken)
+	}
+}
@@ -0,0 +1,219 @@
+package network
+
+import (
+	"encoding/json"
+	"os"
+	"project-group-81/config"
+	"project-group-81/types"
+	"sort"
+	"time"
+)
+
+// Hall calls seen by the master per hour of the day and floor, used by the demand parking policy
+type demandHistory struct {
+	Calls [24][config.NUMBER_OF_FLOORS]int
+}
+
+// Reads the history from DEMAND_HISTORY_FILE, if there is one
+func loadDemandHistory() *demandHistory {
+	history := &demandHistory{}
+	if config.DEMAND_HISTORY_FILE == "" {
+		return history
+	}
+	blob, err := os.ReadFile(config.DEMAND_HISTORY_FILE)
+	if err == nil {
+		err = json.Unmarshal(blob, history)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to read demand history, starting a new one", "file", config.DEMAND_HISTORY_FILE, "err", err)
+		history = &demandHistory{}
+	}
+	return history
+}
+
+func (d *demandHistory) record(order types.Order, now time.Time) {
+	d.Calls[now.Hour()][order.F]++
+	if config.DEMAND_HISTORY_FILE == "" {
+		return
+	}
+	blob, err := json.Marshal(d)
+	if err == nil {
+		err = os.WriteFile(config.DEMAND_HISTORY_FILE, blob, 0644)
+	}
+	if err != nil {
+		log.Warn("Failed to save demand history", "file", config.DEMAND_HISTORY_FILE, "err", err)
+	}
+}
+
+// Floors that have had hall calls in the hour, busiest first
+func (d *demandHistory) busiestFloors(hour int) []types.Floor {
+	var floors []types.Floor
+	for f, calls := range d.Calls[hour] {
+		if calls > 0 {
+			floors = append(floors, f)
+		}
+	}
+	sort.SliceStable(floors, func(i, j int) bool {
+		return d.Calls[hour][floors[i]] > d.Calls[hour][floors[j]]
+	})
+	return floors
+}
+
+// Whether the elevator has nothing to do. Elevators on their way to a parking floor have no orders and are idle too.
+func idle(p Process) bool {
+	return p.Active && p.Elevator.AcceptsHallOrders() && p.Elevator.Orders.IsEmpty()
+}
+
+// Chooses parking floors for elevators that have been idle for PARKING_DELAY, following PARKING_POLICY.
+// Returns the elevators whose parking floor changed. Parking is forgotten as soon as an elevator gets an order.
+func (n *NetworkNode) parkIdleElevators(now time.Time) map[int]types.Floor {
+	if config.PARKING_POLICY == "none" {
+		return nil
+	}
+	mutex.Lock()
+	processes := append([]Process{}, n.Processes...)
+	mutex.Unlock()
+
+	var parkable []Process
+	var occupied []types.Floor // Floors of the other elevators that can serve hall orders
+	for _, p := range processes {
+		if p.Observer {
+			continue
+		}
+		if !idle(p) {
+			delete(n.idleSince, p.Id)
+			delete(n.parkingFloors, p.Id)
+			if p.Active && p.Elevator.AcceptsHallOrders() {
+				occupied = append(occupied, p.Elevator.LastFloor)
+			}
+			continue
+		}
+		if _, found := n.idleSince[p.Id]; !found {
+			n.idleSince[p.Id] = now
+		}
+		if now.Sub(n.idleSince[p.Id]) < config.PARKING_DELAY {
+			occupied = append(occupied, p.Elevator.LastFloor)
+		} else {
+			if floor, found := n.parkingFloors[p.Id]; found && p.Elevator.State == types.Moving {
+				p.Elevator.LastFloor = floor // Already on the way
+			}
+			parkable = append(parkable, p)
+		}
+	}
+	if len(parkable) == 0 {
+		return nil
+	}
+
+	var targets map[int]types.Floor
+	switch config.PARKING_POLICY {
+	case "lobby":
+		targets = make(map[int]types.Floor)
+		for _, p := range parkable {
+			targets[p.Id] = nearestServedFloor(p, config.LOBBY_FLOOR)
+		}
+	case "spread":
+		targets = spreadTargets(parkable, occupied)
+	case "demand":
+		targets = demandTargets(parkable, occupied, n.demand.busiestFloors(now.Hour()))
+	}
+
+	changed := make(map[int]types.Floor)
+	for _, p := range parkable {
+		if floor, found := targets[p.Id]; found && floor != p.Elevator.LastFloor {
+			changed[p.Id] = floor
+			n.parkingFloors[p.Id] = floor
+		}
+	}
+	return changed
+}
+
+// Divides the building into one zone per elevator and parks the idle ones in the middle of the zones
+// not already covered by another elevator
+func spreadTargets(parkable []Process, occupied []types.Floor) map[int]types.Floor {
+	zones := len(parkable) + len(occupied)
+	var centers []types.Floor
+	for i := 0; i < zones; i++ {
+		centers = append(centers, (2*i+1)*config.NUMBER_OF_FLOORS/(2*zones))
+	}
+	for _, floor := range occupied {
+		nearest := 0
+		for i, center := range centers {
+			if abs(center-floor) < abs(centers[nearest]-floor) {
+				nearest = i
+			}
+		}
+		centers = append(centers[:nearest], centers[nearest+1:]...)
+	}
+	return nearestMatching(parkable, centers)
+}
+
+// Parks idle elevators on the busiest floors of the hour that have no elevator yet. Spreads them
+// out if there is no history for the hour.
+func demandTargets(parkable []Process, occupied []types.Floor, busiest []types.Floor) map[int]types.Floor {
+	if len(busiest) == 0 {
+		return spreadTargets(parkable, occupied)
+	}
+	var floors []types.Floor
+	for _, floor := range busiest {
+		if len(floors) < len(parkable) && !containsFloor(occupied, floor) {
+			floors = append(floors, floor)
+		}
+	}
+	return nearestMatching(parkable, floors)
+}
+
+// Repeatedly pairs the elevator and floor closest to each other, so elevators move as little as possible.
+// Elevators are only paired with floors they serve.
+func nearestMatching(parkable []Process, floors []types.Floor) map[int]types.Floor {
+	targets := make(map[int]types.Floor)
+	taken := make([]bool, len(floors))
+	for len(targets) < len(parkable) && len(targets) < len(floors) {
+		bestProcess, bestFloor := -1, -1
+		for i, p := range parkable {
+			if _, found := targets[p.Id]; found {
+				continue
+			}
+			for j, floor := range floors {
+				if taken[j] || !p.Elevator.ServesFloor(floor) {
+					continue
+				}
+				if bestProcess < 0 || abs(floor-p.Elevator.LastFloor) < abs(floors[bestFloor]-parkable[bestProcess].Elevator.LastFloor) {
+					bestProcess, bestFloor = i, j
+				}
+			}
+		}
+		if bestProcess < 0 {
+			break // The remaining elevators serve none of the remaining floors
+		}
+		targets[parkable[bestProcess].Id] = floors[bestFloor]
+		taken[bestFloor] = true
+	}
+	return targets
+}
+
+// The floor closest to the given one that the elevator serves, preferring the lower one on ties
+func nearestServedFloor(p Process, floor types.Floor) types.Floor {
+	nearest := floor
+	for f := config.NUMBER_OF_FLOORS - 1; f >= 0; f-- {
+		if p.Elevator.ServesFloor(f) && (!p.Elevator.ServesFloor(nearest) || abs(f-floor) <= abs(nearest-floor)) {
+			nearest = f
+		}
+	}
+	return nearest
+}
+
+func containsFloor(floors []types.Floor, floor types.Floor) bool {
+	for _, f := range floors {
+		if f == floor {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,82 @@
+package network
+
+import (
+	"fmt"
+	"project-group-81/config"
+	"project-group-81/types"
+	"time"
+)
+
+// Moves unserved hall orders to elevators that can serve them clearly better than their current
+// owner. An order is only moved if the new cost is at least REOPTIMISE_MARGIN lower, and not
+// again within REOPTIMISE_HOLD_TIME, so orders do not ping-pong between elevators. Orders the
+// owner is already stopping for are left alone. Returns whether any order was moved.
+func (n *NetworkNode) reoptimiseOrders() bool {
+	mutex.Lock()
+	processes := make([]Process, len(n.Processes))
+	for i, p := range n.Processes {
+		p.Elevator.Orders = copyOrders(p.Elevator.Orders)
+		processes[i] = p
+	}
+	mutex.Unlock()
+
+	changed := false
+	for i, assignedOrder := range n.AssignedOrders {
+		order := assignedOrder.Order
+		owner := processIndex(processes, assignedOrder.Id)
+		if owner < 0 || !processes[owner].Active {
+			continue // Handled by reassignOrders
+		}
+		if processes[owner].Elevator.IsStoppingFor(order) {
+			continue
+		}
+		// Orders of faulty elevators, or of elevators given floors they do not serve because no other
+		// elevator could take them, are moved as soon as there is a better one to take them
+		mustMove := !processes[owner].Elevator.AcceptsHallOrders() || !processes[owner].Elevator.ServesOrder(order)
+		if !mustMove && time.Since(n.lastReassigned[order]) < config.REOPTIMISE_HOLD_TIME {
+			continue
+		}
+
+		// Compare the owner as if it did not have the order yet, like every other candidate
+		processes[owner].Elevator.Orders.Remove(order)
+		explanation := n.assigner.Explain(order, processes)
+		best := AssignedOrder{explanation.Chosen, order}
+		newOwner := processIndex(processes, best.Id)
+		if newOwner < 0 || newOwner == owner {
+			processes[owner].Elevator.Orders.Insert(order)
+			continue
+		}
+		currentCost := n.assigner.Cost(order, processes[owner].Elevator)
+		newCost := n.assigner.Cost(order, processes[newOwner].Elevator)
+		if !mustMove && float64(newCost) > float64(currentCost)*(1-config.REOPTIMISE_MARGIN) {
+			processes[owner].Elevator.Orders.Insert(order)
+			continue
+		}
+
+		explanation.Reason = fmt.Sprintf("reoptimised from node %d, cost %d -> %d", assignedOrder.Id, currentCost, newCost)
+		recordExplanation(explanation)
+		processes[newOwner].Elevator.Orders.Insert(order)
+		n.AssignedOrders[i] = best
+		n.lastReassigned[order] = time.Now()
+		recordReassignment(order, assignedOrder.Id, best.Id)
+		changed = true
+	}
+	return changed
+}
+
+func processIndex(processes []Process, id int) int {
+	for i, p := range processes {
+		if p.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyOrders(orders types.OrderSet) types.OrderSet {
+	copied := make(types.OrderSet)
+	for o := range orders {
+		copied.Insert(o)
+	}
+	return copied
+}
@@ -3,75 +3,158 @@ package network
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"net"
+	"project-group-81/config"
 	"project-group-81/elevator"
 	"project-group-81/types"
 )
 
 func listenToMaster(
-	conn net.Conn,
-	assignedOrdersChan chan<- []AssignedOrder,
+	packets *packetReader,
+	snapshotChan chan<- OrdersSnapshot,
+	deltaChan chan<- OrdersDelta,
 	slavesConsistentChan chan<- bool,
 	processesChan chan<- []Process,
-	masterUnreachableChan chan<- bool) {
-
-	buf := make([]byte, MESSAGE_BUFFER_LENGTH)
+	parkingChan chan<- map[int]types.Floor,
+	carRequestChan chan<- CarRequest,
+	masterUnreachableChan chan<- bool,
+	detector *failureDetector,
+	done <-chan bool) {
 
 	for {
-		length, err := patientRead(conn, buf, MASTER_RESPONSE_TIMEOUT)
+		message, err := packets.next()
 		if err != nil {
-			fmt.Printf("Error receiving from master: %v\n", err)
+			log.Warn("Failed to receive from master", "err", err)
 			masterUnreachableChan <- true
 			return
-		} else if length == 0 {
+		}
+		detector.heartbeat(MASTER_PEER_ID) // Any message from the master proves it is alive
+		if len(message) == 0 {
 			continue
 		}
 
-		for _, message := range decode(buf[:length]) {
-			messageFlag := message[0]
-			switch messageFlag {
-			case ASSIGNED_ORDERS_FLAG:
-				trimMessage := bytes.Trim(message[1:], "\x00")
-				var assignedOrders []AssignedOrder
-				err = json.Unmarshal(trimMessage, &assignedOrders)
-				if err != nil {
-					fmt.Printf("Error unmarshalling master message, got: %s\n", trimMessage)
-				}
-				assignedOrdersChan <- assignedOrders
-			case PROCESSES_FLAG:
-				trimMessage := bytes.Trim(message[1:], "\x00")
-				var processes []Process
-				err = json.Unmarshal(trimMessage, &processes)
-				if err != nil {
-					fmt.Printf("Error unmarshalling master message, got: %s\n", trimMessage)
-				}
-				processesChan <- processes
-			case CONFIRMATION_FLAG:
-				slavesConsistentChan <- true
+		messageFlag := message[0]
+		switch messageFlag {
+		case ASSIGNED_ORDERS_FLAG:
+			trimMessage := bytes.Trim(message[1:], "\x00")
+			var snapshot OrdersSnapshot
+			err = json.Unmarshal(trimMessage, &snapshot)
+			if err != nil {
+				log.Warn("Failed to unmarshal master message", "message", string(trimMessage), "err", err)
+				continue
+			}
+			select {
+			case snapshotChan <- snapshot:
+			case <-done:
+				return
+			}
+		case ORDERS_DELTA_FLAG:
+			trimMessage := bytes.Trim(message[1:], "\x00")
+			var delta OrdersDelta
+			err = json.Unmarshal(trimMessage, &delta)
+			if err != nil {
+				log.Warn("Failed to unmarshal master message", "message", string(trimMessage), "err", err)
+				continue
+			}
+			select {
+			case deltaChan <- delta:
+			case <-done:
+				return
+			}
+		case PROCESSES_FLAG:
+			trimMessage := bytes.Trim(message[1:], "\x00")
+			var processes []Process
+			err = json.Unmarshal(trimMessage, &processes)
+			if err != nil {
+				log.Warn("Failed to unmarshal master message", "message", string(trimMessage), "err", err)
+			}
+			select {
+			case processesChan <- processes:
+			case <-done:
+				return
+			}
+		case CONFIRMATION_FLAG:
+			select {
+			case slavesConsistentChan <- true:
+			case <-done:
+				return
+			}
+		case PARK_FLAG:
+			trimMessage := bytes.Trim(message[1:], "\x00")
+			var targets map[int]types.Floor
+			err = json.Unmarshal(trimMessage, &targets)
+			if err != nil {
+				log.Warn("Failed to unmarshal master message", "message", string(trimMessage), "err", err)
+				continue
+			}
+			select {
+			case parkingChan <- targets:
+			case <-done:
+				return
+			}
+		case CAR_REQUEST_FLAG:
+			trimMessage := bytes.Trim(message[1:], "\x00")
+			var request CarRequest
+			err = json.Unmarshal(trimMessage, &request)
+			if err != nil {
+				log.Warn("Failed to unmarshal master message", "message", string(trimMessage), "err", err)
+				continue
+			}
+			select {
+			case carRequestChan <- request:
+			case <-done:
+				return
 			}
 		}
-
 	}
 }
 
 func (n *NetworkNode) slaveRun(
 	masterConn net.Conn,
+	masterPackets *packetReader,
 	newOrderChan,
 	finishedOrderChan chan types.Order, // Two-directional channel because listenToMaster forwards messages to this thread
 	lightOnChan,
 	lightOffChan chan<- types.Order,
 	stateChan chan elevator.Elevator,
-	assignedOrderChan chan<- types.Order) {
+	assignedOrderChan,
+	revokedOrderChan chan<- types.Order,
+	connectedChan chan<- bool,
+	parkChan chan<- types.Floor,
+	statusRequestChan <-chan chan Status,
+	reassignChan <-chan bool,
+	carRequestChan <-chan CarRequest,
+	remoteButtonChan chan<- types.Order,
+	serviceChan chan<- bool) {
 
 	slavesConsistentChan := make(chan bool)
-	masterUnreachableChan := make(chan bool)
-	assignedOrdersChan := make(chan []AssignedOrder)
+	masterUnreachableChan := make(chan bool, 1) // Buffered so listenToMaster can exit after the slave has moved on
+	snapshotChan := make(chan OrdersSnapshot)
+	deltaChan := make(chan OrdersDelta)
 	processesChan := make(chan []Process)
+	parkingChan := make(chan map[int]types.Floor)
+	masterCarRequestChan := make(chan CarRequest)
+	detector := newFailureDetector()
+	done := make(chan bool) // Stops the goroutines serving the connection to the master
 
-	go listenToMaster(masterConn, assignedOrdersChan, slavesConsistentChan, processesChan, masterUnreachableChan)
+	masterId := n.processAt(masterConn.RemoteAddr())
+	reinitialize := func() {
+		close(done)
+		detector.stop()
+		masterConn.Close()
+		n.ReinitializeNode(masterId, newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan, revokedOrderChan, connectedChan, parkChan, statusRequestChan, reassignChan, carRequestChan, remoteButtonChan, serviceChan)
+	}
 
-	fmt.Printf("Running slave %d.\n", n.Id)
+	detector.heartbeat(MASTER_PEER_ID)
+	n.Version = 0 // Unknown until the master has sent a snapshot
+	n.requestSnapshot(masterConn, reinitialize)
+	go listenToMaster(masterPackets, snapshotChan, deltaChan, slavesConsistentChan, processesChan, parkingChan, masterCarRequestChan, masterUnreachableChan, detector, done)
+	go sendHeartbeats(masterConn, done)
+
+	n.announceRole("slave")
+	log.Info("Running slave", "peer", masterConn.RemoteAddr().String())
+	n.updateProcessMetrics(masterId)
+	connectedChan <- true
 	for {
 		select {
 		case order := <-newOrderChan:
@@ -81,11 +164,11 @@ func (n *NetworkNode) slaveRun(
 					buf := append([]byte{NEW_ORDER_FLAG}, toSend...)
 					err := patientWrite(masterConn, buf, MASTER_RESPONSE_TIMEOUT)
 					if err != nil {
-						fmt.Printf("Reinitializing after failing to send new order.\n")
-						n.ReinitializeNode(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+						log.Warn("Reinitializing after failing to send new order", "order", order, "err", err)
+						reinitialize()
 					}
 				} else {
-					fmt.Printf("Failed to marshal new order.\n")
+					log.Error("Failed to marshal new order", "order", order, "err", err)
 				}
 			}
 		case order := <-finishedOrderChan:
@@ -94,11 +177,11 @@ func (n *NetworkNode) slaveRun(
 				buf := append([]byte{FINISHED_ORDER_FLAG}, toSend...)
 				err := patientWrite(masterConn, buf, MASTER_RESPONSE_TIMEOUT)
 				if err != nil {
-					fmt.Printf("Reinitializing after failing to send finished order.\n")
-					n.ReinitializeNode(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+					log.Warn("Reinitializing after failing to send finished order", "order", order, "err", err)
+					reinitialize()
 				}
 			} else {
-				fmt.Printf("Failed to marshal finished order.\n")
+				log.Error("Failed to marshal finished order", "order", order, "err", err)
 			}
 		case elevator := <-stateChan:
 			toSend, err := json.Marshal(elevator)
@@ -106,43 +189,85 @@ func (n *NetworkNode) slaveRun(
 				buf := append([]byte{ELEVATOR_STATE_FLAG}, toSend...)
 				err := patientWrite(masterConn, buf, MASTER_RESPONSE_TIMEOUT)
 				if err != nil {
-					fmt.Printf("Reinitializing after failing to send new state.\n")
-					n.ReinitializeNode(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+					log.Warn("Reinitializing after failing to send new state", "err", err)
+					reinitialize()
 				}
 			} else {
-				fmt.Printf("Failed to marshal state\n")
+				log.Error("Failed to marshal state", "err", err)
 			}
 		case <-slavesConsistentChan:
-			diff := recentlyAssignedOrders(n.AssignedOrders, n.PreviousAssignedOrders)
-			for _, order := range diff {
-				lightOnChan <- order.Order
-				if order.Id == n.Id {
-					assignedOrderChan <- order.Order
-				}
+			n.applyConfirmedOrders(lightOnChan, lightOffChan, assignedOrderChan, revokedOrderChan)
+		case snapshot := <-snapshotChan:
+			n.AssignedOrders = snapshot.Orders
+			n.Version = snapshot.Version
+			n.Epoch = snapshot.Epoch
+			n.announceRole("slave")
+			n.sendDigest(masterConn, reinitialize)
+		case delta := <-deltaChan:
+			orders, err := updateOrders(n.AssignedOrders, n.Version, delta)
+			if err != nil {
+				log.Warn("Requesting snapshot of assigned orders", "err", err)
+				n.requestSnapshot(masterConn, reinitialize)
+				break
 			}
-			diff = recentlyAssignedOrders(n.PreviousAssignedOrders, n.AssignedOrders)
-			for _, order := range diff {
-				lightOffChan <- order.Order
-			}
-			n.PreviousAssignedOrders = append([]AssignedOrder{}, n.AssignedOrders...)
-		case orders := <-assignedOrdersChan:
 			n.AssignedOrders = orders
-			toSend, err := json.Marshal(orders)
-			if err == nil {
-				buf := append([]byte{ASSIGNED_ORDERS_FLAG}, toSend...)
-				err := patientWrite(masterConn, buf, MASTER_RESPONSE_TIMEOUT)
-				if err != nil {
-					fmt.Printf("Reinitializing after failing to send assigned orders.\n")
-					n.ReinitializeNode(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
-				}
-			} else {
-				fmt.Printf("Failed to marshal received AssignedOrders.\n")
-			}
+			n.Version = delta.Version
+			n.sendDigest(masterConn, reinitialize)
 		case processes := <-processesChan:
 			n.Processes = processes
+			n.updateProcessMetrics(masterId)
+		case targets := <-parkingChan:
+			if floor, found := targets[n.Id]; found {
+				parkChan <- floor
+			}
+		case reply := <-statusRequestChan:
+			reply <- n.status("slave", n.processAt(masterConn.RemoteAddr()))
+		case <-reassignChan:
+			err := patientWrite(masterConn, []byte{REASSIGN_REQUEST_FLAG}, MASTER_RESPONSE_TIMEOUT)
+			if err != nil {
+				log.Warn("Reinitializing after failing to request reassignment", "err", err)
+				reinitialize()
+			}
+		case request := <-carRequestChan:
+			if request.Car == n.Id {
+				deliverCarRequest(request, remoteButtonChan, serviceChan)
+			} else if err := sendCarRequest(masterConn, request); err != nil {
+				log.Warn("Reinitializing after failing to send car request", "peer", request.Car, "err", err)
+				reinitialize()
+			}
+		case request := <-masterCarRequestChan:
+			if request.Car == n.Id {
+				deliverCarRequest(request, remoteButtonChan, serviceChan)
+			}
+		case event := <-detector.events:
+			if !event.Alive {
+				log.Warn("Master suspected dead without heartbeat, reinitializing", "timeout", config.SUSPECT_TIMEOUT)
+				reinitialize()
+			}
 		case <-masterUnreachableChan:
-			fmt.Printf("Reinitializing because master is unreachable.\n")
-			n.ReinitializeNode(newOrderChan, finishedOrderChan, lightOnChan, lightOffChan, stateChan, assignedOrderChan)
+			log.Warn("Reinitializing because master is unreachable")
+			reinitialize()
 		}
 	}
 }
+
+func (n *NetworkNode) sendDigest(masterConn net.Conn, reinitialize func()) {
+	toSend, err := json.Marshal(n.digest())
+	if err != nil {
+		log.Error("Failed to marshal assigned orders digest", "err", err)
+		return
+	}
+	err = patientWrite(masterConn, append([]byte{ORDERS_DIGEST_FLAG}, toSend...), MASTER_RESPONSE_TIMEOUT)
+	if err != nil {
+		log.Warn("Reinitializing after failing to send assigned orders digest", "err", err)
+		reinitialize()
+	}
+}
+
+func (n *NetworkNode) requestSnapshot(masterConn net.Conn, reinitialize func()) {
+	err := patientWrite(masterConn, []byte{SNAPSHOT_REQUEST_FLAG}, MASTER_RESPONSE_TIMEOUT)
+	if err != nil {
+		log.Warn("Reinitializing after failing to request snapshot", "err", err)
+		reinitialize()
+	}
+}
@@ -0,0 +1,78 @@
+package network
+
+import (
+	"project-group-81/journal"
+	"project-group-81/logging"
+	"project-group-81/types"
+	"project-group-81/webhook"
+)
+
+// What the node currently knows about the network
+type Status struct {
+	Role                   string // "master", "slave" or "observer"
+	Id                     int
+	MasterId               int // -1 if the master is not among the known processes
+	Epoch                  int
+	Version                int
+	Processes              []Process
+	AssignedOrders         []AssignedOrder
+	PreviousAssignedOrders []AssignedOrder
+}
+
+// Copy of the node's view that the caller may keep while the node goes on changing it
+func (n *NetworkNode) status(role string, masterId int) Status {
+	mutex.Lock()
+	defer mutex.Unlock()
+	processes := make([]Process, len(n.Processes))
+	for i, p := range n.Processes {
+		p.Elevator.Orders = copyOrders(p.Elevator.Orders)
+		processes[i] = p
+	}
+	return Status{
+		Role:                   role,
+		Id:                     n.Id,
+		MasterId:               masterId,
+		Epoch:                  n.Epoch,
+		Version:                n.Version,
+		Processes:              processes,
+		AssignedOrders:         append([]AssignedOrder{}, n.AssignedOrders...),
+		PreviousAssignedOrders: append([]AssignedOrder{}, n.PreviousAssignedOrders...)}
+}
+
+// Tells the logger and the journal which node this is from now on, and what it does
+func (n *NetworkNode) announceRole(role string) {
+	logging.SetNode(n.Id, role, n.Epoch)
+	journal.SetNode(n.Id)
+	webhook.SetNode(n.Id)
+}
+
+// Assigns every order again as if it was new, for when an operator asks for it. Orders an elevator is
+// already stopping for stay with it, as with reoptimisation.
+func (n *NetworkNode) redistributeOrders(reason string) {
+	log.Info("Redistributing all orders", "reason", reason)
+	orders := n.AssignedOrders
+	n.AssignedOrders = []AssignedOrder{}
+	for _, assignedOrder := range orders {
+		if n.isStoppingFor(assignedOrder.Id, assignedOrder.Order) {
+			n.AssignedOrders = append(n.AssignedOrders, assignedOrder)
+			continue
+		}
+		reassigned := n.assign(assignedOrder.Order, reason)
+		if reassigned.Id != assignedOrder.Id {
+			recordReassignment(reassigned.Order, assignedOrder.Id, reassigned.Id)
+		}
+		n.AssignedOrders = append(n.AssignedOrders, reassigned)
+	}
+}
+
+// Whether the active elevator of the node has stopped, or is about to stop, to serve the order
+func (n *NetworkNode) isStoppingFor(id int, order types.Order) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, p := range n.Processes {
+		if p.Id == id {
+			return p.Active && p.Elevator.IsStoppingFor(order)
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package network
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Full copy of the assigned orders. Only sent when a slave joins or its state does not match the master's.
+type OrdersSnapshot struct {
+	Epoch   int
+	Version int
+	Orders  []AssignedOrder
+}
+
+// Incremental change taking the assigned orders from BaseVersion to Version
+type OrdersDelta struct {
+	BaseVersion int
+	Version     int
+	Added       []AssignedOrder
+	Removed     []AssignedOrder
+	Hash        string // Hash of the assigned orders after the delta is applied
+}
+
+// Sent by slaves after every update, so the master can check consistency without the full state
+type OrdersDigest struct {
+	Version int
+	Hash    string
+}
+
+type slaveDigest struct {
+	Id     int
+	Digest OrdersDigest
+}
+
+// Order-independent hash of a set of assigned orders
+func hashOrders(orders []AssignedOrder) string {
+	encoded := make([]string, 0, len(orders))
+	for _, order := range orders {
+		blob, err := json.Marshal(order)
+		if err != nil {
+			log.Error("Failed to marshal assigned order for hashing", "order", order.Order, "peer", order.Id, "err", err)
+		}
+		encoded = append(encoded, string(blob))
+	}
+	sort.Strings(encoded)
+	hash := sha256.New()
+	for _, blob := range encoded {
+		hash.Write([]byte(blob))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// The delta taking the orders published at the version to the current orders
+func ordersDelta(published []AssignedOrder, orders []AssignedOrder, version int) OrdersDelta {
+	return OrdersDelta{
+		BaseVersion: version,
+		Version:     version + 1,
+		Added:       recentlyAssignedOrders(orders, published),
+		Removed:     recentlyAssignedOrders(published, orders),
+		Hash:        hashOrders(orders)}
+}
+
+func applyDelta(orders []AssignedOrder, delta OrdersDelta) []AssignedOrder {
+	result := []AssignedOrder{}
+	for _, order := range orders {
+		if len(recentlyAssignedOrders([]AssignedOrder{order}, delta.Removed)) != 0 {
+			result = append(result, order)
+		}
+	}
+	for _, order := range delta.Added {
+		if len(recentlyAssignedOrders([]AssignedOrder{order}, result)) != 0 {
+			result = append(result, order)
+		}
+	}
+	return result
+}
+
+// Takes the orders at the version through the delta. Fails when the delta starts at another version or
+// does not end at the master's orders, and the slave has to ask for a snapshot instead.
+func updateOrders(orders []AssignedOrder, version int, delta OrdersDelta) ([]AssignedOrder, error) {
+	if delta.BaseVersion != version {
+		return nil, fmt.Errorf("missed version %d of the assigned orders", delta.BaseVersion)
+	}
+	orders = applyDelta(orders, delta)
+	if hashOrders(orders) != delta.Hash {
+		return nil, fmt.Errorf("assigned orders diverged from the master at version %d", delta.Version)
+	}
+	return orders, nil
+}
+
+// Compares a slave's digest with the master's orders. A digest older than the master's version answers
+// a delta that has since been replaced, and is neither consistent nor in need of a snapshot.
+func (n *NetworkNode) checkDigest(digest OrdersDigest) (consistent bool, needsSnapshot bool) {
+	if digest.Version < n.Version {
+		return false, false
+	}
+	consistent = digest == n.digest()
+	return consistent, !consistent
+}
+
+func (n *NetworkNode) digest() OrdersDigest {
+	return OrdersDigest{n.Version, hashOrders(n.AssignedOrders)}
+}
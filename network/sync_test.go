@@ -0,0 +1,114 @@
+package network
+
+import (
+	"encoding/json"
+	"project-group-81/types"
+	"testing"
+)
+
+var (
+	up2      = AssignedOrder{0, types.Order{C: types.HallUp, F: 2}}
+	down3    = AssignedOrder{1, types.Order{C: types.HallDown, F: 3}}
+	car1     = AssignedOrder{1, types.Order{C: types.Car, F: 1}}
+	up2Moved = AssignedOrder{1, up2.Order} // up2 reassigned from car 0 to car 1
+)
+
+func sameOrders(a []AssignedOrder, b []AssignedOrder) bool {
+	return len(a) == len(b) && len(recentlyAssignedOrders(a, b)) == 0 && len(recentlyAssignedOrders(b, a)) == 0
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		published []AssignedOrder
+		orders    []AssignedOrder
+	}{
+		{"first order", nil, []AssignedOrder{up2}},
+		{"added", []AssignedOrder{up2}, []AssignedOrder{up2, down3, car1}},
+		{"removed", []AssignedOrder{up2, down3}, []AssignedOrder{down3}},
+		{"all removed", []AssignedOrder{up2, down3}, []AssignedOrder{}},
+		{"reassigned", []AssignedOrder{up2, down3}, []AssignedOrder{down3, up2Moved}},
+		{"reassigned and back", []AssignedOrder{up2Moved}, []AssignedOrder{up2}},
+		{"reordered", []AssignedOrder{up2, down3}, []AssignedOrder{down3, up2}},
+	} {
+		var delta OrdersDelta
+		blob, err := json.Marshal(ordersDelta(c.published, c.orders, 7))
+		if err == nil {
+			err = json.Unmarshal(blob, &delta)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if delta.BaseVersion != 7 || delta.Version != 8 {
+			t.Errorf("%s: delta from version %d to %d, want 7 to 8", c.name, delta.BaseVersion, delta.Version)
+		}
+		got, err := updateOrders(c.published, 7, delta)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !sameOrders(got, c.orders) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.orders)
+		}
+	}
+}
+
+func TestReassignmentDelta(t *testing.T) {
+	delta := ordersDelta([]AssignedOrder{up2, down3}, []AssignedOrder{down3, up2Moved}, 0)
+	if len(delta.Removed) != 1 || delta.Removed[0] != up2 || len(delta.Added) != 1 || delta.Added[0] != up2Moved {
+		t.Errorf("got removed %v and added %v, want %v moved to %v", delta.Removed, delta.Added, up2, up2Moved)
+	}
+}
+
+func TestHashOrders(t *testing.T) {
+	if hashOrders([]AssignedOrder{up2, down3, car1}) != hashOrders([]AssignedOrder{car1, up2, down3}) {
+		t.Error("hash depends on the order of the orders")
+	}
+	if hashOrders(nil) != hashOrders([]AssignedOrder{}) {
+		t.Error("no orders hash differently when nil")
+	}
+	for _, orders := range [][]AssignedOrder{nil, {down3}, {up2Moved, down3}, {up2, down3, car1}} {
+		if hashOrders(orders) == hashOrders([]AssignedOrder{up2, down3}) {
+			t.Errorf("%v hashes like %v", orders, []AssignedOrder{up2, down3})
+		}
+	}
+}
+
+func TestUpdateOrdersNeedsSnapshot(t *testing.T) {
+	published := []AssignedOrder{up2}
+	delta := ordersDelta(published, []AssignedOrder{up2, down3}, 4)
+	for _, c := range []struct {
+		name    string
+		orders  []AssignedOrder
+		version int
+	}{
+		{"missed a version", published, 3},
+		{"ahead of the master", published, 5},
+		{"diverged", []AssignedOrder{up2, car1}, 4},
+		{"reassignment missed", []AssignedOrder{up2Moved}, 4},
+	} {
+		if orders, err := updateOrders(c.orders, c.version, delta); err == nil {
+			t.Errorf("%s: got %v, want a snapshot requested", c.name, orders)
+		}
+	}
+}
+
+func TestCheckDigest(t *testing.T) {
+	master := &NetworkNode{Version: 5, AssignedOrders: []AssignedOrder{up2, down3}}
+	current := hashOrders(master.AssignedOrders)
+	stale := hashOrders([]AssignedOrder{up2})
+	for _, c := range []struct {
+		name          string
+		digest        OrdersDigest
+		consistent    bool
+		needsSnapshot bool
+	}{
+		{"consistent", OrdersDigest{5, current}, true, false},
+		{"answer to an older delta", OrdersDigest{4, stale}, false, false},
+		{"diverged", OrdersDigest{5, stale}, false, true},
+		{"version ahead of the master", OrdersDigest{6, current}, false, true},
+	} {
+		consistent, needsSnapshot := master.checkDigest(c.digest)
+		if consistent != c.consistent || needsSnapshot != c.needsSnapshot {
+			t.Errorf("%s: got consistent %v and snapshot %v, want %v and %v", c.name, consistent, needsSnapshot, c.consistent, c.needsSnapshot)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package network
 
 import (
-	"fmt"
+	"net"
 	"project-group-81/elevator"
 	"project-group-81/types"
 	"strconv"
-	"strings"
+	"time"
 )
 
 type NetworkPacket struct {
@@ -24,12 +24,15 @@ type Socket struct {
 }
 
 func (s Socket) String() string {
-	return fmt.Sprintf("%s:%s", s.Address, s.Port)
+	return net.JoinHostPort(s.Address, s.Port)
 }
 
 func FromString(s string) Socket {
-	splits := strings.Split(s, ":")
-	return Socket{splits[0], splits[1]}
+	address, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return Socket{}
+	}
+	return Socket{address, port}
 }
 
 func (s1 *Socket) Equals(s2 Socket) bool {
@@ -38,15 +41,16 @@ func (s1 *Socket) Equals(s2 Socket) bool {
 
 func (s *Socket) Valid() bool {
 	_, err := strconv.Atoi(s.Port)
-	return strings.Count(s.Address, ".") == 3 && err == nil
+	return net.ParseIP(s.Address) != nil && err == nil
 }
 
 type Process struct {
 	Id             int
 	Socket         Socket
 	Active         bool
-	ElevatorSocket Socket
+	ElevatorSocket Socket // Identifies the node when it joins again. Observers make one up, as they have no elevator.
 	Elevator       elevator.Elevator
+	Observer       bool // Has no elevator, so it is never assigned orders, waited for or made master
 }
 
 type NetworkNode struct {
@@ -54,4 +58,15 @@ type NetworkNode struct {
 	AssignedOrders         []AssignedOrder
 	PreviousAssignedOrders []AssignedOrder
 	Processes              []Process
+	Version                int             // Version of AssignedOrders, increased by the master on every change
+	Epoch                  int             // Master epoch, increased every time a node takes over as master
+	publishedOrders        []AssignedOrder // AssignedOrders as of the last delta sent by the master
+	assigner               Assigner
+	lastReassigned         map[types.Order]time.Time // When reoptimisation last moved each order
+	idleSince              map[int]time.Time         // When each idle elevator last got its final order served
+	parkingFloors          map[int]types.Floor       // Where each parked elevator was sent
+	demand                 *demandHistory
+	hallCallTimes          map[types.Order]time.Time // When the master got each unserved order, for the wait metric
+	hallWaitAlerts         types.OrderSet            // Unserved orders a webhook event was sent for, as they waited too long
+	roundStarted           time.Time                 // When the assigned orders last changed without being confirmed yet
 }
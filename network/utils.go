@@ -2,22 +2,13 @@ package network
 
 import (
 	"fmt"
-	"log"
 	"net"
+	"project-group-81/journal"
+	"project-group-81/metrics"
 	"project-group-81/types"
-	"strings"
 	"time"
 )
 
-func interfaceAvailable() bool {
-	byNameInterface, err := net.InterfaceByName(INTERFACE_NAME)
-	if err != nil {
-		fmt.Printf("Error, interface %s is not available", INTERFACE_NAME)
-		return true
-	}
-	return strings.Contains(byNameInterface.Flags.String(), "up")
-}
-
 func getFreePort() (int, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -31,18 +22,6 @@ func getFreePort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
-func GetIPAddress() string {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer conn.Close()
-
-	localAddr := conn.LocalAddr().(*net.UDPAddr)
-
-	return localAddr.IP.String()
-}
-
 func contains(assignedOrders []AssignedOrder, order types.Order) bool {
 	for _, assignedOrder := range assignedOrders {
 		if assignedOrder.Order == order {
@@ -69,38 +48,91 @@ func recentlyAssignedOrders(newSlice []AssignedOrder, oldSlice []AssignedOrder)
 	return difference
 }
 
+// Moves orders away from elevators that are inactive or cannot serve hall orders.
+// The light stays on; applyConfirmedOrders tells the old owner, if reachable, to drop the order.
 func (n *NetworkNode) reassignOrders() {
-	fmt.Printf("Reassigning orders.\n")
-	aliveIds := make(map[int]bool)
-	for _, process := range n.Processes {
-		if process.Active {
-			aliveIds[process.Id] = true
+	log.Debug("Reassigning orders")
+	for i, assignedOrder := range n.AssignedOrders {
+		if !isCandidate(assignedOrder.Id, assignedOrder.Order, n.Processes) {
+			explanation := n.assigner.Explain(assignedOrder.Order, n.Processes)
+			explanation.Reason = fmt.Sprintf("node %d can no longer serve it", assignedOrder.Id)
+			recordExplanation(explanation)
+			n.AssignedOrders[i] = AssignedOrder{explanation.Chosen, assignedOrder.Order}
+			if explanation.Chosen != assignedOrder.Id {
+				recordReassignment(assignedOrder.Order, assignedOrder.Id, explanation.Chosen)
+			}
 		}
 	}
-	for i, assignedOrder := range n.AssignedOrders {
-		if _, found := aliveIds[assignedOrder.Id]; !found {
-			assignedOrder = Assign(assignedOrder.Order, n.Processes)
-			n.AssignedOrders[i] = assignedOrder
-			// Pretend as if order is new by removing it from PreviousAssignedOrders
-			for i, previousAssignOrder := range n.PreviousAssignedOrders {
-				if previousAssignOrder.Order == assignedOrder.Order {
-					n.PreviousAssignedOrders = append(n.PreviousAssignedOrders[:i], n.PreviousAssignedOrders[i+1:]...)
-				}
+}
+
+// Counts and journals an order moving from one elevator to another
+func recordReassignment(order types.Order, previous, assignee int) {
+	metrics.OrdersReassigned.Inc(order.C.String())
+	journal.RecordAssignment(journal.Reassigned, order, previous, assignee)
+}
+
+// Updates lights and the local elevator after all active nodes have confirmed the assigned orders
+func (n *NetworkNode) applyConfirmedOrders(
+	lightOnChan,
+	lightOffChan,
+	assignedOrderChan,
+	revokedOrderChan chan<- types.Order) {
+
+	for _, order := range recentlyAssignedOrders(n.AssignedOrders, n.PreviousAssignedOrders) {
+		journal.RecordAssignment(journal.Confirmed, order.Order, -1, order.Id)
+		lightOnChan <- order.Order
+		if order.Id == n.Id {
+			assignedOrderChan <- order.Order
+		}
+		if order.Order.C == types.Destination {
+			log.Info("Passengers take elevator", "order", order.Order, "floor", order.Order.F, "destination", order.Order.D, "peer", order.Id)
+		}
+	}
+	for _, order := range recentlyAssignedOrders(n.PreviousAssignedOrders, n.AssignedOrders) {
+		if contains(n.AssignedOrders, order.Order) {
+			if order.Id == n.Id { // Moved to another elevator, the light stays on
+				revokedOrderChan <- order.Order
 			}
+		} else if buttonInUse(n.AssignedOrders, order.Order.Button()) {
+			lightOnChan <- order.Order // Still shown for other destination orders, even if the elevator turned it off
+		} else {
+			lightOffChan <- order.Order
 		}
 	}
+	n.PreviousAssignedOrders = append([]AssignedOrder{}, n.AssignedOrders...)
+}
+
+// Whether an assigned order is shown on the button. Destination orders share hall buttons.
+func buttonInUse(orders []AssignedOrder, button types.Order) bool {
+	for _, o := range orders {
+		if o.Order.Button() == button {
+			return true
+		}
+	}
+	return false
 }
 
 func activeSlavesConsistent(processes []Process, consistentSlaves map[int]bool) bool {
 	allConsistent := true
 	for _, node := range processes {
-		if node.Active {
+		if node.Active && !node.Observer {
 			allConsistent = allConsistent && consistentSlaves[node.Id]
 		}
 	}
 	return allConsistent
 }
 
+func (n *NetworkNode) isObserver(id int) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, process := range n.Processes {
+		if process.Id == id {
+			return process.Observer
+		}
+	}
+	return false
+}
+
 func (n *NetworkNode) getOwnProcess() Process {
 	for _, process := range n.Processes {
 		if process.Id == n.Id {
@@ -119,7 +151,7 @@ func patientWrite(conn net.Conn, message []byte, timeout time.Duration) error {
 		} else if interfaceAvailable() {
 			return err
 		} else {
-			continue
+			waitForInterface()
 		}
 	}
 }
@@ -133,7 +165,7 @@ func patientRead(conn net.Conn, buf []byte, timeout time.Duration) (int, error)
 		} else if interfaceAvailable() {
 			return length, err
 		} else {
-			continue
+			waitForInterface()
 		}
 	}
 }
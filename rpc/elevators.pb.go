@@ -0,0 +1,1162 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: elevators.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Call int32
+
+const (
+	Call_CALL_UNSPECIFIED Call = 0
+	Call_CALL_HALL_UP     Call = 1
+	Call_CALL_HALL_DOWN   Call = 2
+	Call_CALL_CAR         Call = 3
+	Call_CALL_DESTINATION Call = 4 // Hall call with the destination entered at the hall
+)
+
+// Enum value maps for Call.
+var (
+	Call_name = map[int32]string{
+		0: "CALL_UNSPECIFIED",
+		1: "CALL_HALL_UP",
+		2: "CALL_HALL_DOWN",
+		3: "CALL_CAR",
+		4: "CALL_DESTINATION",
+	}
+	Call_value = map[string]int32{
+		"CALL_UNSPECIFIED": 0,
+		"CALL_HALL_UP":     1,
+		"CALL_HALL_DOWN":   2,
+		"CALL_CAR":         3,
+		"CALL_DESTINATION": 4,
+	}
+)
+
+func (x Call) Enum() *Call {
+	p := new(Call)
+	*p = x
+	return p
+}
+
+func (x Call) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Call) Descriptor() protoreflect.EnumDescriptor {
+	return file_elevators_proto_enumTypes[0].Descriptor()
+}
+
+func (Call) Type() protoreflect.EnumType {
+	return &file_elevators_proto_enumTypes[0]
+}
+
+func (x Call) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Call.Descriptor instead.
+func (Call) EnumDescriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{0}
+}
+
+type Direction int32
+
+const (
+	Direction_DIRECTION_IDLE Direction = 0
+	Direction_DIRECTION_UP   Direction = 1
+	Direction_DIRECTION_DOWN Direction = 2
+)
+
+// Enum value maps for Direction.
+var (
+	Direction_name = map[int32]string{
+		0: "DIRECTION_IDLE",
+		1: "DIRECTION_UP",
+		2: "DIRECTION_DOWN",
+	}
+	Direction_value = map[string]int32{
+		"DIRECTION_IDLE": 0,
+		"DIRECTION_UP":   1,
+		"DIRECTION_DOWN": 2,
+	}
+)
+
+func (x Direction) Enum() *Direction {
+	p := new(Direction)
+	*p = x
+	return p
+}
+
+func (x Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_elevators_proto_enumTypes[1].Descriptor()
+}
+
+func (Direction) Type() protoreflect.EnumType {
+	return &file_elevators_proto_enumTypes[1]
+}
+
+func (x Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Direction.Descriptor instead.
+func (Direction) EnumDescriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{1}
+}
+
+type OrderEventKind int32
+
+const (
+	OrderEventKind_ORDER_EVENT_KIND_UNSPECIFIED OrderEventKind = 0
+	OrderEventKind_ORDER_EVENT_KIND_ASSIGNED    OrderEventKind = 1
+	OrderEventKind_ORDER_EVENT_KIND_REASSIGNED  OrderEventKind = 2
+	OrderEventKind_ORDER_EVENT_KIND_CONFIRMED   OrderEventKind = 3
+	OrderEventKind_ORDER_EVENT_KIND_SERVED      OrderEventKind = 4
+)
+
+// Enum value maps for OrderEventKind.
+var (
+	OrderEventKind_name = map[int32]string{
+		0: "ORDER_EVENT_KIND_UNSPECIFIED",
+		1: "ORDER_EVENT_KIND_ASSIGNED",
+		2: "ORDER_EVENT_KIND_REASSIGNED",
+		3: "ORDER_EVENT_KIND_CONFIRMED",
+		4: "ORDER_EVENT_KIND_SERVED",
+	}
+	OrderEventKind_value = map[string]int32{
+		"ORDER_EVENT_KIND_UNSPECIFIED": 0,
+		"ORDER_EVENT_KIND_ASSIGNED":    1,
+		"ORDER_EVENT_KIND_REASSIGNED":  2,
+		"ORDER_EVENT_KIND_CONFIRMED":   3,
+		"ORDER_EVENT_KIND_SERVED":      4,
+	}
+)
+
+func (x OrderEventKind) Enum() *OrderEventKind {
+	p := new(OrderEventKind)
+	*p = x
+	return p
+}
+
+func (x OrderEventKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderEventKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_elevators_proto_enumTypes[2].Descriptor()
+}
+
+func (OrderEventKind) Type() protoreflect.EnumType {
+	return &file_elevators_proto_enumTypes[2]
+}
+
+func (x OrderEventKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderEventKind.Descriptor instead.
+func (OrderEventKind) EnumDescriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{2}
+}
+
+type Order struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Call        Call  `protobuf:"varint,1,opt,name=call,proto3,enum=elevators.Call" json:"call,omitempty"`
+	Floor       int32 `protobuf:"varint,2,opt,name=floor,proto3" json:"floor,omitempty"`
+	Destination int32 `protobuf:"varint,3,opt,name=destination,proto3" json:"destination,omitempty"` // Only for destination calls
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Order) GetCall() Call {
+	if x != nil {
+		return x.Call
+	}
+	return Call_CALL_UNSPECIFIED
+}
+
+func (x *Order) GetFloor() int32 {
+	if x != nil {
+		return x.Floor
+	}
+	return 0
+}
+
+func (x *Order) GetDestination() int32 {
+	if x != nil {
+		return x.Destination
+	}
+	return 0
+}
+
+type SubmitOrderRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Order *Order `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	Car   int32  `protobuf:"varint,2,opt,name=car,proto3" json:"car,omitempty"` // Car of a cab call, ignored for hall calls
+}
+
+func (x *SubmitOrderRequest) Reset() {
+	*x = SubmitOrderRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitOrderRequest) ProtoMessage() {}
+
+func (x *SubmitOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitOrderRequest.ProtoReflect.Descriptor instead.
+func (*SubmitOrderRequest) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitOrderRequest) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *SubmitOrderRequest) GetCar() int32 {
+	if x != nil {
+		return x.Car
+	}
+	return 0
+}
+
+type SubmitOrderReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SubmitOrderReply) Reset() {
+	*x = SubmitOrderReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubmitOrderReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitOrderReply) ProtoMessage() {}
+
+func (x *SubmitOrderReply) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitOrderReply.ProtoReflect.Descriptor instead.
+func (*SubmitOrderReply) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{2}
+}
+
+type SetServiceModeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Car       int32 `protobuf:"varint,1,opt,name=car,proto3" json:"car,omitempty"`
+	InService bool  `protobuf:"varint,2,opt,name=in_service,json=inService,proto3" json:"in_service,omitempty"`
+}
+
+func (x *SetServiceModeRequest) Reset() {
+	*x = SetServiceModeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetServiceModeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetServiceModeRequest) ProtoMessage() {}
+
+func (x *SetServiceModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetServiceModeRequest.ProtoReflect.Descriptor instead.
+func (*SetServiceModeRequest) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SetServiceModeRequest) GetCar() int32 {
+	if x != nil {
+		return x.Car
+	}
+	return 0
+}
+
+func (x *SetServiceModeRequest) GetInService() bool {
+	if x != nil {
+		return x.InService
+	}
+	return false
+}
+
+type SetServiceModeReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SetServiceModeReply) Reset() {
+	*x = SetServiceModeReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetServiceModeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetServiceModeReply) ProtoMessage() {}
+
+func (x *SetServiceModeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetServiceModeReply.ProtoReflect.Descriptor instead.
+func (*SetServiceModeReply) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{4}
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{5}
+}
+
+type ElevatorState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Car         int32     `protobuf:"varint,1,opt,name=car,proto3" json:"car,omitempty"`
+	Active      bool      `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
+	Master      bool      `protobuf:"varint,3,opt,name=master,proto3" json:"master,omitempty"` // The node of the car is the master
+	Floor       int32     `protobuf:"varint,4,opt,name=floor,proto3" json:"floor,omitempty"`
+	Direction   Direction `protobuf:"varint,5,opt,name=direction,proto3,enum=elevators.Direction" json:"direction,omitempty"`
+	DoorOpen    bool      `protobuf:"varint,6,opt,name=door_open,json=doorOpen,proto3" json:"door_open,omitempty"`
+	Orders      []*Order  `protobuf:"bytes,7,rep,name=orders,proto3" json:"orders,omitempty"`     // Orders the car is serving, cab calls included
+	Assigned    []*Order  `protobuf:"bytes,8,rep,name=assigned,proto3" json:"assigned,omitempty"` // Hall orders the master has assigned to the car
+	Load        int32     `protobuf:"varint,9,opt,name=load,proto3" json:"load,omitempty"`        // Percent of capacity
+	StopPressed bool      `protobuf:"varint,10,opt,name=stop_pressed,json=stopPressed,proto3" json:"stop_pressed,omitempty"`
+	MotorFault  bool      `protobuf:"varint,11,opt,name=motor_fault,json=motorFault,proto3" json:"motor_fault,omitempty"`
+	Obstructed  bool      `protobuf:"varint,12,opt,name=obstructed,proto3" json:"obstructed,omitempty"`
+	InService   bool      `protobuf:"varint,13,opt,name=in_service,json=inService,proto3" json:"in_service,omitempty"`
+	Blockers    []string  `protobuf:"bytes,14,rep,name=blockers,proto3" json:"blockers,omitempty"` // Why the car takes no hall calls, empty when it does
+}
+
+func (x *ElevatorState) Reset() {
+	*x = ElevatorState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ElevatorState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ElevatorState) ProtoMessage() {}
+
+func (x *ElevatorState) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ElevatorState.ProtoReflect.Descriptor instead.
+func (*ElevatorState) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ElevatorState) GetCar() int32 {
+	if x != nil {
+		return x.Car
+	}
+	return 0
+}
+
+func (x *ElevatorState) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *ElevatorState) GetMaster() bool {
+	if x != nil {
+		return x.Master
+	}
+	return false
+}
+
+func (x *ElevatorState) GetFloor() int32 {
+	if x != nil {
+		return x.Floor
+	}
+	return 0
+}
+
+func (x *ElevatorState) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DIRECTION_IDLE
+}
+
+func (x *ElevatorState) GetDoorOpen() bool {
+	if x != nil {
+		return x.DoorOpen
+	}
+	return false
+}
+
+func (x *ElevatorState) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *ElevatorState) GetAssigned() []*Order {
+	if x != nil {
+		return x.Assigned
+	}
+	return nil
+}
+
+func (x *ElevatorState) GetLoad() int32 {
+	if x != nil {
+		return x.Load
+	}
+	return 0
+}
+
+func (x *ElevatorState) GetStopPressed() bool {
+	if x != nil {
+		return x.StopPressed
+	}
+	return false
+}
+
+func (x *ElevatorState) GetMotorFault() bool {
+	if x != nil {
+		return x.MotorFault
+	}
+	return false
+}
+
+func (x *ElevatorState) GetObstructed() bool {
+	if x != nil {
+		return x.Obstructed
+	}
+	return false
+}
+
+func (x *ElevatorState) GetInService() bool {
+	if x != nil {
+		return x.InService
+	}
+	return false
+}
+
+func (x *ElevatorState) GetBlockers() []string {
+	if x != nil {
+		return x.Blockers
+	}
+	return nil
+}
+
+type Assignment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Order     *Order `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	Car       int32  `protobuf:"varint,2,opt,name=car,proto3" json:"car,omitempty"`
+	Confirmed bool   `protobuf:"varint,3,opt,name=confirmed,proto3" json:"confirmed,omitempty"` // Every active node has the assignment, so the hall light is on
+}
+
+func (x *Assignment) Reset() {
+	*x = Assignment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Assignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assignment) ProtoMessage() {}
+
+func (x *Assignment) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assignment.ProtoReflect.Descriptor instead.
+func (*Assignment) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Assignment) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *Assignment) GetCar() int32 {
+	if x != nil {
+		return x.Car
+	}
+	return 0
+}
+
+func (x *Assignment) GetConfirmed() bool {
+	if x != nil {
+		return x.Confirmed
+	}
+	return false
+}
+
+type Assignments struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Epoch       int32         `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`     // Increased every time a node takes over as master
+	Version     int32         `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Increased by the master on every change
+	Assignments []*Assignment `protobuf:"bytes,3,rep,name=assignments,proto3" json:"assignments,omitempty"`
+}
+
+func (x *Assignments) Reset() {
+	*x = Assignments{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Assignments) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assignments) ProtoMessage() {}
+
+func (x *Assignments) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assignments.ProtoReflect.Descriptor instead.
+func (*Assignments) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Assignments) GetEpoch() int32 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *Assignments) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Assignments) GetAssignments() []*Assignment {
+	if x != nil {
+		return x.Assignments
+	}
+	return nil
+}
+
+type OrderEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Time        *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"` // When the node serving the stream saw the change
+	Kind        OrderEventKind         `protobuf:"varint,2,opt,name=kind,proto3,enum=elevators.OrderEventKind" json:"kind,omitempty"`
+	Order       *Order                 `protobuf:"bytes,3,opt,name=order,proto3" json:"order,omitempty"`
+	Car         int32                  `protobuf:"varint,4,opt,name=car,proto3" json:"car,omitempty"`
+	PreviousCar int32                  `protobuf:"varint,5,opt,name=previous_car,json=previousCar,proto3" json:"previous_car,omitempty"` // Car the order was moved from, only for reassigned orders
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_elevators_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_elevators_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_elevators_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OrderEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetKind() OrderEventKind {
+	if x != nil {
+		return x.Kind
+	}
+	return OrderEventKind_ORDER_EVENT_KIND_UNSPECIFIED
+}
+
+func (x *OrderEvent) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetCar() int32 {
+	if x != nil {
+		return x.Car
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetPreviousCar() int32 {
+	if x != nil {
+		return x.PreviousCar
+	}
+	return 0
+}
+
+var File_elevators_proto protoreflect.FileDescriptor
+
+var file_elevators_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x09, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x1a, 0x1f, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x64, 0x0a,
+	0x05, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x04, 0x63, 0x61, 0x6c, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73,
+	0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x52, 0x04, 0x63, 0x61, 0x6c, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x66,
+	0x6c, 0x6f, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x66, 0x6c, 0x6f, 0x6f,
+	0x72, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0x4e, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x6f, 0x72, 0x64,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61,
+	0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x05, 0x6f, 0x72, 0x64, 0x65,
+	0x72, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03,
+	0x63, 0x61, 0x72, 0x22, 0x12, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x48, 0x0a, 0x15, 0x53, 0x65, 0x74, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x63,
+	0x61, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x22, 0x15, 0x0a, 0x13, 0x53, 0x65, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4d,
+	0x6f, 0x64, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x0e, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xc3, 0x03, 0x0a, 0x0d, 0x45, 0x6c, 0x65,
+	0x76, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x61,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x63, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06,
+	0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05,
+	0x66, 0x6c, 0x6f, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x66, 0x6c, 0x6f,
+	0x6f, 0x72, 0x12, 0x32, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x6f, 0x6f, 0x72, 0x5f, 0x6f,
+	0x70, 0x65, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x6f, 0x6f, 0x72, 0x4f,
+	0x70, 0x65, 0x6e, 0x12, 0x28, 0x0a, 0x06, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x06, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x12, 0x2c, 0x0a,
+	0x08, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x52, 0x08, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c,
+	0x6f, 0x61, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x12,
+	0x21, 0x0a, 0x0c, 0x73, 0x74, 0x6f, 0x70, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x73, 0x74, 0x6f, 0x70, 0x50, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x6f, 0x74, 0x6f, 0x72, 0x5f, 0x66, 0x61, 0x75, 0x6c,
+	0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6d, 0x6f, 0x74, 0x6f, 0x72, 0x46, 0x61,
+	0x75, 0x6c, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x6f, 0x62, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x65,
+	0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6f, 0x62, 0x73, 0x74, 0x72, 0x75, 0x63,
+	0x74, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x0e,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x72, 0x73, 0x22, 0x64,
+	0x0a, 0x0a, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x26, 0x0a, 0x05,
+	0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x65, 0x6c,
+	0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x05, 0x6f,
+	0x72, 0x64, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x03, 0x63, 0x61, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72,
+	0x6d, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x72, 0x6d, 0x65, 0x64, 0x22, 0x76, 0x0a, 0x0b, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x0b, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61,
+	0x74, 0x6f, 0x72, 0x73, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x52,
+	0x0b, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xc8, 0x01, 0x0a,
+	0x0a, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x04, 0x6b,
+	0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x65, 0x6c, 0x65, 0x76,
+	0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x26, 0x0a, 0x05, 0x6f, 0x72,
+	0x64, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x65, 0x6c, 0x65, 0x76,
+	0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x05, 0x6f, 0x72, 0x64,
+	0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x03, 0x63, 0x61, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73,
+	0x5f, 0x63, 0x61, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x70, 0x72, 0x65, 0x76,
+	0x69, 0x6f, 0x75, 0x73, 0x43, 0x61, 0x72, 0x2a, 0x66, 0x0a, 0x04, 0x43, 0x61, 0x6c, 0x6c, 0x12,
+	0x14, 0x0a, 0x10, 0x43, 0x41, 0x4c, 0x4c, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x41, 0x4c, 0x4c, 0x5f, 0x48, 0x41,
+	0x4c, 0x4c, 0x5f, 0x55, 0x50, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x43, 0x41, 0x4c, 0x4c, 0x5f,
+	0x48, 0x41, 0x4c, 0x4c, 0x5f, 0x44, 0x4f, 0x57, 0x4e, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x43,
+	0x41, 0x4c, 0x4c, 0x5f, 0x43, 0x41, 0x52, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x4c,
+	0x4c, 0x5f, 0x44, 0x45, 0x53, 0x54, 0x49, 0x4e, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x04, 0x2a,
+	0x45, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x0e,
+	0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x49, 0x44, 0x4c, 0x45, 0x10, 0x00,
+	0x12, 0x10, 0x0a, 0x0c, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x55, 0x50,
+	0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
+	0x44, 0x4f, 0x57, 0x4e, 0x10, 0x02, 0x2a, 0xaf, 0x01, 0x0a, 0x0e, 0x4f, 0x72, 0x64, 0x65, 0x72,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x20, 0x0a, 0x1c, 0x4f, 0x52, 0x44,
+	0x45, 0x52, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1d, 0x0a, 0x19, 0x4f,
+	0x52, 0x44, 0x45, 0x52, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f,
+	0x41, 0x53, 0x53, 0x49, 0x47, 0x4e, 0x45, 0x44, 0x10, 0x01, 0x12, 0x1f, 0x0a, 0x1b, 0x4f, 0x52,
+	0x44, 0x45, 0x52, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x52,
+	0x45, 0x41, 0x53, 0x53, 0x49, 0x47, 0x4e, 0x45, 0x44, 0x10, 0x02, 0x12, 0x1e, 0x0a, 0x1a, 0x4f,
+	0x52, 0x44, 0x45, 0x52, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f,
+	0x43, 0x4f, 0x4e, 0x46, 0x49, 0x52, 0x4d, 0x45, 0x44, 0x10, 0x03, 0x12, 0x1b, 0x0a, 0x17, 0x4f,
+	0x52, 0x44, 0x45, 0x52, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f,
+	0x53, 0x45, 0x52, 0x56, 0x45, 0x44, 0x10, 0x04, 0x32, 0xfe, 0x02, 0x0a, 0x09, 0x45, 0x6c, 0x65,
+	0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x49, 0x0a, 0x0b, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x1d, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73,
+	0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x12, 0x52, 0x0a, 0x0e, 0x53, 0x65, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4d,
+	0x6f, 0x64, 0x65, 0x12, 0x20, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e,
+	0x53, 0x65, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x2e, 0x53, 0x65, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x45, 0x0a, 0x0e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x45, 0x6c,
+	0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x17, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74,
+	0x6f, 0x72, 0x73, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x18, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x45, 0x6c, 0x65,
+	0x76, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x30, 0x01, 0x12, 0x45, 0x0a, 0x10,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x17, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x65, 0x6c, 0x65, 0x76,
+	0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x10, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x17, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74,
+	0x6f, 0x72, 0x73, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x15, 0x2e, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x42, 0x16, 0x5a, 0x14, 0x70, 0x72, 0x6f,
+	0x6a, 0x65, 0x63, 0x74, 0x2d, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x2d, 0x38, 0x31, 0x2f, 0x72, 0x70,
+	0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_elevators_proto_rawDescOnce sync.Once
+	file_elevators_proto_rawDescData = file_elevators_proto_rawDesc
+)
+
+func file_elevators_proto_rawDescGZIP() []byte {
+	file_elevators_proto_rawDescOnce.Do(func() {
+		file_elevators_proto_rawDescData = protoimpl.X.CompressGZIP(file_elevators_proto_rawDescData)
+	})
+	return file_elevators_proto_rawDescData
+}
+
+var file_elevators_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_elevators_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_elevators_proto_goTypes = []any{
+	(Call)(0),                     // 0: elevators.Call
+	(Direction)(0),                // 1: elevators.Direction
+	(OrderEventKind)(0),           // 2: elevators.OrderEventKind
+	(*Order)(nil),                 // 3: elevators.Order
+	(*SubmitOrderRequest)(nil),    // 4: elevators.SubmitOrderRequest
+	(*SubmitOrderReply)(nil),      // 5: elevators.SubmitOrderReply
+	(*SetServiceModeRequest)(nil), // 6: elevators.SetServiceModeRequest
+	(*SetServiceModeReply)(nil),   // 7: elevators.SetServiceModeReply
+	(*WatchRequest)(nil),          // 8: elevators.WatchRequest
+	(*ElevatorState)(nil),         // 9: elevators.ElevatorState
+	(*Assignment)(nil),            // 10: elevators.Assignment
+	(*Assignments)(nil),           // 11: elevators.Assignments
+	(*OrderEvent)(nil),            // 12: elevators.OrderEvent
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_elevators_proto_depIdxs = []int32{
+	0,  // 0: elevators.Order.call:type_name -> elevators.Call
+	3,  // 1: elevators.SubmitOrderRequest.order:type_name -> elevators.Order
+	1,  // 2: elevators.ElevatorState.direction:type_name -> elevators.Direction
+	3,  // 3: elevators.ElevatorState.orders:type_name -> elevators.Order
+	3,  // 4: elevators.ElevatorState.assigned:type_name -> elevators.Order
+	3,  // 5: elevators.Assignment.order:type_name -> elevators.Order
+	10, // 6: elevators.Assignments.assignments:type_name -> elevators.Assignment
+	13, // 7: elevators.OrderEvent.time:type_name -> google.protobuf.Timestamp
+	2,  // 8: elevators.OrderEvent.kind:type_name -> elevators.OrderEventKind
+	3,  // 9: elevators.OrderEvent.order:type_name -> elevators.Order
+	4,  // 10: elevators.Elevators.SubmitOrder:input_type -> elevators.SubmitOrderRequest
+	6,  // 11: elevators.Elevators.SetServiceMode:input_type -> elevators.SetServiceModeRequest
+	8,  // 12: elevators.Elevators.WatchElevators:input_type -> elevators.WatchRequest
+	8,  // 13: elevators.Elevators.WatchAssignments:input_type -> elevators.WatchRequest
+	8,  // 14: elevators.Elevators.WatchOrderEvents:input_type -> elevators.WatchRequest
+	5,  // 15: elevators.Elevators.SubmitOrder:output_type -> elevators.SubmitOrderReply
+	7,  // 16: elevators.Elevators.SetServiceMode:output_type -> elevators.SetServiceModeReply
+	9,  // 17: elevators.Elevators.WatchElevators:output_type -> elevators.ElevatorState
+	11, // 18: elevators.Elevators.WatchAssignments:output_type -> elevators.Assignments
+	12, // 19: elevators.Elevators.WatchOrderEvents:output_type -> elevators.OrderEvent
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_elevators_proto_init() }
+func file_elevators_proto_init() {
+	if File_elevators_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_elevators_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Order); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*SubmitOrderRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*SubmitOrderReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*SetServiceModeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*SetServiceModeReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ElevatorState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Assignment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Assignments); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_elevators_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*OrderEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_elevators_proto_rawDesc,
+			NumEnums:      3,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_elevators_proto_goTypes,
+		DependencyIndexes: file_elevators_proto_depIdxs,
+		EnumInfos:         file_elevators_proto_enumTypes,
+		MessageInfos:      file_elevators_proto_msgTypes,
+	}.Build()
+	File_elevators_proto = out.File
+	file_elevators_proto_rawDesc = nil
+	file_elevators_proto_goTypes = nil
+	file_elevators_proto_depIdxs = nil
+}
@@ -0,0 +1,301 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: elevators.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Elevators_SubmitOrder_FullMethodName      = "/elevators.Elevators/SubmitOrder"
+	Elevators_SetServiceMode_FullMethodName   = "/elevators.Elevators/SetServiceMode"
+	Elevators_WatchElevators_FullMethodName   = "/elevators.Elevators/WatchElevators"
+	Elevators_WatchAssignments_FullMethodName = "/elevators.Elevators/WatchAssignments"
+	Elevators_WatchOrderEvents_FullMethodName = "/elevators.Elevators/WatchOrderEvents"
+)
+
+// ElevatorsClient is the client API for Elevators service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The elevators of the building as the current master sees them. Every node serves it: hall calls go
+// to the master as if pressed on the node's panel, and requests for the elevator of another node are
+// passed on to that node through the master.
+type ElevatorsClient interface {
+	// Places a hall call, or a cab call in the given car
+	SubmitOrder(ctx context.Context, in *SubmitOrderRequest, opts ...grpc.CallOption) (*SubmitOrderReply, error)
+	// Takes a car out of hall service or puts it back
+	SetServiceMode(ctx context.Context, in *SetServiceModeRequest, opts ...grpc.CallOption) (*SetServiceModeReply, error)
+	// The state of every car, and of each car again whenever it changes
+	WatchElevators(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ElevatorState], error)
+	// The assigned hall orders, and all of them again whenever they change
+	WatchAssignments(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Assignments], error)
+	// Hall orders as they are assigned, reassigned, confirmed and served from now on
+	WatchOrderEvents(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error)
+}
+
+type elevatorsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewElevatorsClient(cc grpc.ClientConnInterface) ElevatorsClient {
+	return &elevatorsClient{cc}
+}
+
+func (c *elevatorsClient) SubmitOrder(ctx context.Context, in *SubmitOrderRequest, opts ...grpc.CallOption) (*SubmitOrderReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitOrderReply)
+	err := c.cc.Invoke(ctx, Elevators_SubmitOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elevatorsClient) SetServiceMode(ctx context.Context, in *SetServiceModeRequest, opts ...grpc.CallOption) (*SetServiceModeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetServiceModeReply)
+	err := c.cc.Invoke(ctx, Elevators_SetServiceMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elevatorsClient) WatchElevators(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ElevatorState], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Elevators_ServiceDesc.Streams[0], Elevators_WatchElevators_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, ElevatorState]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Elevators_WatchElevatorsClient = grpc.ServerStreamingClient[ElevatorState]
+
+func (c *elevatorsClient) WatchAssignments(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Assignments], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Elevators_ServiceDesc.Streams[1], Elevators_WatchAssignments_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Assignments]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Elevators_WatchAssignmentsClient = grpc.ServerStreamingClient[Assignments]
+
+func (c *elevatorsClient) WatchOrderEvents(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Elevators_ServiceDesc.Streams[2], Elevators_WatchOrderEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, OrderEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Elevators_WatchOrderEventsClient = grpc.ServerStreamingClient[OrderEvent]
+
+// ElevatorsServer is the server API for Elevators service.
+// All implementations must embed UnimplementedElevatorsServer
+// for forward compatibility.
+//
+// The elevators of the building as the current master sees them. Every node serves it: hall calls go
+// to the master as if pressed on the node's panel, and requests for the elevator of another node are
+// passed on to that node through the master.
+type ElevatorsServer interface {
+	// Places a hall call, or a cab call in the given car
+	SubmitOrder(context.Context, *SubmitOrderRequest) (*SubmitOrderReply, error)
+	// Takes a car out of hall service or puts it back
+	SetServiceMode(context.Context, *SetServiceModeRequest) (*SetServiceModeReply, error)
+	// The state of every car, and of each car again whenever it changes
+	WatchElevators(*WatchRequest, grpc.ServerStreamingServer[ElevatorState]) error
+	// The assigned hall orders, and all of them again whenever they change
+	WatchAssignments(*WatchRequest, grpc.ServerStreamingServer[Assignments]) error
+	// Hall orders as they are assigned, reassigned, confirmed and served from now on
+	WatchOrderEvents(*WatchRequest, grpc.ServerStreamingServer[OrderEvent]) error
+	mustEmbedUnimplementedElevatorsServer()
+}
+
+// UnimplementedElevatorsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedElevatorsServer struct{}
+
+func (UnimplementedElevatorsServer) SubmitOrder(context.Context, *SubmitOrderRequest) (*SubmitOrderReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitOrder not implemented")
+}
+func (UnimplementedElevatorsServer) SetServiceMode(context.Context, *SetServiceModeRequest) (*SetServiceModeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetServiceMode not implemented")
+}
+func (UnimplementedElevatorsServer) WatchElevators(*WatchRequest, grpc.ServerStreamingServer[ElevatorState]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchElevators not implemented")
+}
+func (UnimplementedElevatorsServer) WatchAssignments(*WatchRequest, grpc.ServerStreamingServer[Assignments]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAssignments not implemented")
+}
+func (UnimplementedElevatorsServer) WatchOrderEvents(*WatchRequest, grpc.ServerStreamingServer[OrderEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrderEvents not implemented")
+}
+func (UnimplementedElevatorsServer) mustEmbedUnimplementedElevatorsServer() {}
+func (UnimplementedElevatorsServer) testEmbeddedByValue()                   {}
+
+// UnsafeElevatorsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ElevatorsServer will
+// result in compilation errors.
+type UnsafeElevatorsServer interface {
+	mustEmbedUnimplementedElevatorsServer()
+}
+
+func RegisterElevatorsServer(s grpc.ServiceRegistrar, srv ElevatorsServer) {
+	// If the following call pancis, it indicates UnimplementedElevatorsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Elevators_ServiceDesc, srv)
+}
+
+func _Elevators_SubmitOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElevatorsServer).SubmitOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Elevators_SubmitOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElevatorsServer).SubmitOrder(ctx, req.(*SubmitOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Elevators_SetServiceMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetServiceModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElevatorsServer).SetServiceMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Elevators_SetServiceMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElevatorsServer).SetServiceMode(ctx, req.(*SetServiceModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Elevators_WatchElevators_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ElevatorsServer).WatchElevators(m, &grpc.GenericServerStream[WatchRequest, ElevatorState]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Elevators_WatchElevatorsServer = grpc.ServerStreamingServer[ElevatorState]
+
+func _Elevators_WatchAssignments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ElevatorsServer).WatchAssignments(m, &grpc.GenericServerStream[WatchRequest, Assignments]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Elevators_WatchAssignmentsServer = grpc.ServerStreamingServer[Assignments]
+
+func _Elevators_WatchOrderEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ElevatorsServer).WatchOrderEvents(m, &grpc.GenericServerStream[WatchRequest, OrderEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Elevators_WatchOrderEventsServer = grpc.ServerStreamingServer[OrderEvent]
+
+// Elevators_ServiceDesc is the grpc.ServiceDesc for Elevators service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Elevators_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "elevators.Elevators",
+	HandlerType: (*ElevatorsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitOrder",
+			Handler:    _Elevators_SubmitOrder_Handler,
+		},
+		{
+			MethodName: "SetServiceMode",
+			Handler:    _Elevators_SetServiceMode_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchElevators",
+			Handler:       _Elevators_WatchElevators_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchAssignments",
+			Handler:       _Elevators_WatchAssignments_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchOrderEvents",
+			Handler:       _Elevators_WatchOrderEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "elevators.proto",
+}
@@ -0,0 +1,279 @@
+// Typed gRPC API of the elevators, defined in elevators.proto
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative elevators.proto
+
+import (
+	"context"
+	"net"
+	"project-group-81/api"
+	"project-group-81/logging"
+	"project-group-81/network"
+	"project-group-81/types"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var log = logging.For("rpc")
+
+const (
+	WATCH_PERIOD    = time.Millisecond * 250 // How often streams check the network node for changes
+	REQUEST_TIMEOUT = time.Second * 2        // Time to wait for the elevator or network node to answer
+)
+
+type server struct {
+	UnimplementedElevatorsServer
+	statusRequestChan chan<- chan network.Status
+	remoteButtonChan  chan<- types.Order
+	carRequestChan    chan<- network.CarRequest
+}
+
+// Serves the gRPC API on the address until it fails. Observer nodes pass the channel their hall calls
+// go to as remoteButtonChan.
+func Serve(
+	address string,
+	statusRequestChan chan<- chan network.Status,
+	remoteButtonChan chan<- types.Order,
+	carRequestChan chan<- network.CarRequest) error {
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	RegisterElevatorsServer(s, &server{statusRequestChan: statusRequestChan, remoteButtonChan: remoteButtonChan, carRequestChan: carRequestChan})
+	log.Info("Serving gRPC API", "address", address)
+	return s.Serve(listener)
+}
+
+func (s *server) SubmitOrder(ctx context.Context, request *SubmitOrderRequest) (*SubmitOrderReply, error) {
+	order, err := toOrder(request.GetOrder())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if order.C != types.Car {
+		select {
+		case s.remoteButtonChan <- order:
+			return &SubmitOrderReply{}, nil
+		case <-time.After(REQUEST_TIMEOUT):
+			return nil, status.Error(codes.Unavailable, "node is not responding")
+		}
+	}
+	if err := s.sendCarRequest(network.CarRequest{Car: int(request.GetCar()), Order: &order}); err != nil {
+		return nil, err
+	}
+	return &SubmitOrderReply{}, nil
+}
+
+func (s *server) SetServiceMode(ctx context.Context, request *SetServiceModeRequest) (*SetServiceModeReply, error) {
+	inService := request.GetInService()
+	if err := s.sendCarRequest(network.CarRequest{Car: int(request.GetCar()), InService: &inService}); err != nil {
+		return nil, err
+	}
+	return &SetServiceModeReply{}, nil
+}
+
+// Hands the request to the network node once the car is known to be connected
+func (s *server) sendCarRequest(request network.CarRequest) error {
+	current, known := s.networkStatus()
+	if !known {
+		return status.Error(codes.Unavailable, "node is searching for a master")
+	}
+	connected := false
+	for _, p := range current.Processes {
+		if p.Id == request.Car && p.Active && !p.Observer {
+			connected = true
+		}
+	}
+	if !connected {
+		return status.Errorf(codes.NotFound, "car %d is not connected", request.Car)
+	}
+	select {
+	case s.carRequestChan <- request:
+		return nil
+	case <-time.After(REQUEST_TIMEOUT):
+		return status.Error(codes.Unavailable, "node is searching for a master")
+	}
+}
+
+func (s *server) WatchElevators(request *WatchRequest, stream Elevators_WatchElevatorsServer) error {
+	sent := make(map[int32]*ElevatorState)
+	return s.watch(stream.Context(), func(current network.Status) error {
+		for _, shaft := range api.NewBuilding(current).Shafts {
+			state := elevatorState(shaft, current)
+			if proto.Equal(state, sent[state.Car]) {
+				continue
+			}
+			if err := stream.Send(state); err != nil {
+				return err
+			}
+			sent[state.Car] = state
+		}
+		return nil
+	})
+}
+
+func (s *server) WatchAssignments(request *WatchRequest, stream Elevators_WatchAssignmentsServer) error {
+	var sent *Assignments
+	return s.watch(stream.Context(), func(current network.Status) error {
+		assignments := &Assignments{Epoch: int32(current.Epoch), Version: int32(current.Version), Assignments: []*Assignment{}}
+		for _, assigned := range current.AssignedOrders {
+			assignments.Assignments = append(assignments.Assignments, &Assignment{
+				Order:     fromOrder(assigned.Order),
+				Car:       int32(assigned.Id),
+				Confirmed: confirmed(current, assigned)})
+		}
+		if sent != nil && proto.Equal(assignments, sent) {
+			return nil
+		}
+		sent = assignments
+		return stream.Send(assignments)
+	})
+}
+
+// Events are found by comparing the assigned orders the node knows of with the ones it knew of before
+func (s *server) WatchOrderEvents(request *WatchRequest, stream Elevators_WatchOrderEventsServer) error {
+	var assignees map[types.Order]int  // Car of every assigned order when last checked
+	var confirmedOrders types.OrderSet // Assigned orders that were confirmed when last checked
+	return s.watch(stream.Context(), func(current network.Status) error {
+		now := timestamppb.Now()
+		var events []*OrderEvent
+		nextAssignees := make(map[types.Order]int)
+		nextConfirmed := make(types.OrderSet)
+		for _, assigned := range current.AssignedOrders {
+			order, car := assigned.Order, assigned.Id
+			nextAssignees[order] = car
+			previous, known := assignees[order]
+			if assignees != nil && !known {
+				events = append(events, &OrderEvent{Time: now, Kind: OrderEventKind_ORDER_EVENT_KIND_ASSIGNED, Order: fromOrder(order), Car: int32(car)})
+			} else if assignees != nil && previous != car {
+				events = append(events, &OrderEvent{Time: now, Kind: OrderEventKind_ORDER_EVENT_KIND_REASSIGNED, Order: fromOrder(order), Car: int32(car), PreviousCar: int32(previous)})
+			}
+			if confirmed(current, assigned) {
+				nextConfirmed.Insert(order)
+				if assignees != nil && !confirmedOrders.Contains(order) {
+					events = append(events, &OrderEvent{Time: now, Kind: OrderEventKind_ORDER_EVENT_KIND_CONFIRMED, Order: fromOrder(order), Car: int32(car)})
+				}
+			}
+		}
+		for order, car := range assignees {
+			if _, found := nextAssignees[order]; !found {
+				events = append(events, &OrderEvent{Time: now, Kind: OrderEventKind_ORDER_EVENT_KIND_SERVED, Order: fromOrder(order), Car: int32(car)})
+			}
+		}
+		assignees, confirmedOrders = nextAssignees, nextConfirmed
+		for _, event := range events {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Calls changed with the status of the node every WATCH_PERIOD until the stream ends. The status is
+// skipped while the node is searching for a master.
+func (s *server) watch(ctx context.Context, changed func(network.Status) error) error {
+	ticker := time.NewTicker(WATCH_PERIOD)
+	defer ticker.Stop()
+	for {
+		if current, known := s.networkStatus(); known {
+			if err := changed(current); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *server) networkStatus() (network.Status, bool) {
+	reply := make(chan network.Status, 1)
+	select {
+	case s.statusRequestChan <- reply:
+		return <-reply, true
+	case <-time.After(REQUEST_TIMEOUT):
+		return network.Status{}, false
+	}
+}
+
+// Whether every active node has the assignment, which is when the hall light is turned on
+func confirmed(current network.Status, assigned network.AssignedOrder) bool {
+	for _, previous := range current.PreviousAssignedOrders {
+		if previous == assigned {
+			return true
+		}
+	}
+	return false
+}
+
+func elevatorState(shaft api.Shaft, current network.Status) *ElevatorState {
+	state := &ElevatorState{
+		Car:       int32(shaft.Id),
+		Active:    shaft.Active,
+		Master:    shaft.Master,
+		Floor:     int32(shaft.Floor),
+		DoorOpen:  shaft.DoorOpen,
+		Orders:    fromOrders(shaft.Orders),
+		Assigned:  fromOrders(shaft.Assigned),
+		Load:      int32(shaft.Load),
+		InService: true,
+		Blockers:  shaft.Blockers}
+	switch shaft.Direction {
+	case "up":
+		state.Direction = Direction_DIRECTION_UP
+	case "down":
+		state.Direction = Direction_DIRECTION_DOWN
+	}
+	for _, p := range current.Processes {
+		if p.Id == shaft.Id {
+			state.StopPressed = p.Elevator.Health.StopPressed
+			state.MotorFault = p.Elevator.Health.MotorFault
+			state.Obstructed = p.Elevator.Health.Obstructed
+			state.InService = !p.Elevator.Health.OutOfService
+		}
+	}
+	return state
+}
+
+var calls = map[types.Call]Call{
+	types.HallUp:      Call_CALL_HALL_UP,
+	types.HallDown:    Call_CALL_HALL_DOWN,
+	types.Car:         Call_CALL_CAR,
+	types.Destination: Call_CALL_DESTINATION,
+}
+
+func fromOrder(order types.Order) *Order {
+	return &Order{Call: calls[order.C], Floor: int32(order.F), Destination: int32(order.D)}
+}
+
+func fromOrders(orders []types.Order) []*Order {
+	converted := []*Order{}
+	for _, order := range orders {
+		converted = append(converted, fromOrder(order))
+	}
+	return converted
+}
+
+// The order to submit, checked the same way as orders posted to the status API
+func toOrder(order *Order) (types.Order, error) {
+	if order.GetCall() == Call_CALL_DESTINATION {
+		return api.ParseDestinationOrder(strconv.Itoa(int(order.GetFloor())), strconv.Itoa(int(order.GetDestination())))
+	}
+	call := "Unknown"
+	for c, converted := range calls {
+		if converted == order.GetCall() {
+			call = c.String()
+		}
+	}
+	return api.ParseOrder(call, strconv.Itoa(int(order.GetFloor())))
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"log/slog"
 )
 
 type MotorDirection byte
@@ -27,11 +28,41 @@ const (
 	HallUp Call = iota
 	HallDown
 	Car
+	Destination // Hall call with the destination entered at the hall, used in destination dispatch mode
 )
 
 type Order struct {
 	C Call
 	F Floor
+	D Floor `json:",omitempty"` // Destination of Destination orders, zero for every other call
+}
+
+// Direction the passengers of a hall or destination order want to travel, MotorHalt for cab orders
+func (o Order) Direction() MotorDirection {
+	switch o.C {
+	case HallUp:
+		return MotorUp
+	case HallDown:
+		return MotorDown
+	case Destination:
+		if o.D > o.F {
+			return MotorUp
+		}
+		return MotorDown
+	}
+	return MotorHalt
+}
+
+// The button whose light shows the order. Destination orders are shown on the hall button of their direction.
+func (o Order) Button() Order {
+	switch o.C {
+	case Destination:
+		if o.Direction() == MotorUp {
+			return Order{C: HallUp, F: o.F}
+		}
+		return Order{C: HallDown, F: o.F}
+	}
+	return o
 }
 
 func (c Call) String() string {
@@ -42,10 +73,20 @@ func (c Call) String() string {
 		return "Car"
 	case HallDown:
 		return "HallDown"
+	case Destination:
+		return "Destination"
 	}
 	return "Unknown"
 }
 
 func (o Order) String() string {
+	if o.C == Destination {
+		return fmt.Sprintf("%s on floor %d to floor %d", o.C, o.F, o.D)
+	}
 	return fmt.Sprintf("%s on floor %d", o.C, o.F)
 }
+
+// Logged as its description in both text and JSON logs
+func (o Order) LogValue() slog.Value {
+	return slog.StringValue(o.String())
+}
@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"project-group-81/config"
+	"project-group-81/logging"
+	"slices"
+	"sync"
+	"time"
+)
+
+var log = logging.For("webhook")
+
+// What happened
+type Kind string
+
+const (
+	ElevatorInactive Kind = "elevator_inactive" // A moving elevator reached no floor within INACTIVE_TIME
+	HardwareErrors   Kind = "hardware_errors"   // HARDWARE_ERROR_LIMIT requests to the hardware failed in a row
+	MasterFailover   Kind = "master_failover"   // The node took over as master after an earlier one
+	NodeInactive     Kind = "node_inactive"     // The master lost contact with a node
+	HallCallWaiting  Kind = "hall_call_waiting" // A hall call has waited for longer than WEBHOOK_HALL_WAIT
+)
+
+var Kinds = []Kind{ElevatorInactive, HardwareErrors, MasterFailover, NodeInactive, HallCallWaiting}
+
+// Undelivered events kept at most, the oldest are dropped first
+const OUTBOX_LIMIT = 1000
+
+// Body of the request posted to the endpoints
+type Event struct {
+	Id      string // The same on every attempt, so endpoints can drop events they already got
+	Time    time.Time
+	Node    int // Node that sent the event, -1 before it has joined a network
+	Kind    Kind
+	Message string
+	Details map[string]interface{} `json:",omitempty"`
+}
+
+// Event waiting to be posted to one endpoint
+type delivery struct {
+	URL         string
+	Event       Event
+	Attempts    int
+	NextAttempt time.Time
+}
+
+var outbox = struct {
+	sync.Mutex
+	node    int
+	pending []delivery // Oldest first
+	file    string     // Where the outbox is kept across restarts, empty to keep it in memory
+	loaded  bool       // The file has been read, so it may be written
+	wake    chan bool  // Tells Run that an event was added
+}{node: -1, wake: make(chan bool, 1)}
+
+// Fails if a kind in the list is not one of Kinds
+func CheckKinds(kinds []string) error {
+	for _, kind := range kinds {
+		if !slices.Contains(Kinds, Kind(kind)) {
+			return fmt.Errorf("webhook event must be one of %v, got %q", Kinds, kind)
+		}
+	}
+	return nil
+}
+
+// Id of this node in the events sent from now on
+func SetNode(id int) {
+	outbox.Lock()
+	defer outbox.Unlock()
+	outbox.node = id
+}
+
+// Posts an event to every endpoint in WEBHOOK_URLS, unless WEBHOOK_EVENTS leaves its kind out. Details
+// are given as pairs of keys and values, as in log calls.
+func Notify(kind Kind, message string, details ...interface{}) {
+	if len(config.WEBHOOK_URLS) == 0 || (len(config.WEBHOOK_EVENTS) > 0 && !slices.Contains(config.WEBHOOK_EVENTS, string(kind))) {
+		return
+	}
+	event := Event{Id: newId(), Time: time.Now(), Kind: kind, Message: message}
+	if len(details) > 0 {
+		event.Details = make(map[string]interface{})
+		for i := 0; i+1 < len(details); i += 2 {
+			event.Details[fmt.Sprint(details[i])] = details[i+1]
+		}
+	}
+
+	outbox.Lock()
+	defer outbox.Unlock()
+	event.Node = outbox.node
+	for _, url := range config.WEBHOOK_URLS {
+		outbox.pending = append(outbox.pending, delivery{URL: url, Event: event, NextAttempt: event.Time})
+	}
+	if len(outbox.pending) > OUTBOX_LIMIT {
+		log.Warn("Webhook outbox is full, dropping the oldest events", "dropped", len(outbox.pending)-OUTBOX_LIMIT)
+		outbox.pending = outbox.pending[len(outbox.pending)-OUTBOX_LIMIT:]
+	}
+	save()
+	select {
+	case outbox.wake <- true:
+	default:
+	}
+}
+
+// Reads the events left in the outbox file and posts every event, retrying failed deliveries with a
+// backoff from WEBHOOK_RETRY_MIN to WEBHOOK_RETRY_MAX until they succeed
+func Run(file string) {
+	load(file)
+	client := &http.Client{Timeout: config.WEBHOOK_TIMEOUT}
+	for {
+		d, found := next()
+		wait := time.Hour
+		if found {
+			wait = time.Until(d.NextAttempt)
+		}
+		if wait > 0 {
+			select {
+			case <-outbox.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+		retry, err := post(client, d)
+		finish(d, retry, err)
+	}
+}
+
+// The pending delivery due first
+func next() (delivery, bool) {
+	outbox.Lock()
+	defer outbox.Unlock()
+	if len(outbox.pending) == 0 {
+		return delivery{}, false
+	}
+	first := outbox.pending[0]
+	for _, d := range outbox.pending[1:] {
+		if d.NextAttempt.Before(first.NextAttempt) {
+			first = d
+		}
+	}
+	return first, true
+}
+
+// Posts the event, and tells whether a failure is worth retrying
+func post(client *http.Client, d delivery) (bool, error) {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return false, err
+	}
+	response, err := client.Post(d.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	// Other client errors mean the endpoint does not want the event, so sending it again will not help
+	retry := response.StatusCode >= 500 || response.StatusCode == http.StatusRequestTimeout || response.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("endpoint answered %s", response.Status)
+}
+
+// Removes the delivery from the outbox, or schedules the next attempt if it should be retried
+func finish(d delivery, retry bool, err error) {
+	outbox.Lock()
+	defer outbox.Unlock()
+	for i, pending := range outbox.pending {
+		if pending.URL != d.URL || pending.Event.Id != d.Event.Id {
+			continue
+		}
+		if err == nil {
+			log.Debug("Posted webhook event", "url", d.URL, "kind", d.Event.Kind, "attempts", d.Attempts+1)
+			outbox.pending = append(outbox.pending[:i], outbox.pending[i+1:]...)
+		} else if !retry {
+			log.Error("Webhook endpoint refused event, dropping it", "url", d.URL, "kind", d.Event.Kind, "err", err)
+			outbox.pending = append(outbox.pending[:i], outbox.pending[i+1:]...)
+		} else {
+			pending.Attempts++
+			pending.NextAttempt = time.Now().Add(backoff(pending.Attempts))
+			outbox.pending[i] = pending
+			log.Warn("Failed to post webhook event, retrying", "url", d.URL, "kind", d.Event.Kind, "attempts", pending.Attempts, "retry_at", pending.NextAttempt, "err", err)
+		}
+		save()
+		return
+	}
+}
+
+// Wait after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	wait := config.WEBHOOK_RETRY_MIN
+	for i := 1; i < attempts && wait < config.WEBHOOK_RETRY_MAX; i++ {
+		wait *= 2
+	}
+	return min(wait, config.WEBHOOK_RETRY_MAX)
+}
+
+// Puts the deliveries left in the file before the ones added since the process started
+func load(file string) {
+	outbox.Lock()
+	defer outbox.Unlock()
+	outbox.file = file
+	outbox.loaded = true
+	if file == "" {
+		return
+	}
+	var saved []delivery
+	blob, err := os.ReadFile(file)
+	if err == nil {
+		err = json.Unmarshal(blob, &saved)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to read webhook outbox, starting a new one", "file", file, "err", err)
+		saved = nil
+	}
+	var kept []delivery
+	for _, d := range saved {
+		if slices.Contains(config.WEBHOOK_URLS, d.URL) {
+			kept = append(kept, d)
+		} else {
+			log.Warn("Dropping webhook event for an endpoint that is no longer configured", "url", d.URL, "kind", d.Event.Kind)
+		}
+	}
+	if len(kept) > 0 {
+		log.Info("Resending webhook events left from before", "events", len(kept))
+	}
+	outbox.pending = append(kept, outbox.pending...)
+	save()
+}
+
+// Writes the outbox to its file, through a temporary file so a crash leaves the old or the new outbox.
+// The caller holds the lock.
+func save() {
+	if outbox.file == "" || !outbox.loaded {
+		return
+	}
+	blob, err := json.Marshal(outbox.pending)
+	if err == nil {
+		temporary := outbox.file + ".tmp"
+		err = os.WriteFile(temporary, blob, 0644)
+		if err == nil {
+			err = os.Rename(temporary, outbox.file)
+		}
+	}
+	if err != nil {
+		log.Warn("Failed to save webhook outbox", "file", outbox.file, "err", err)
+	}
+}
+
+func newId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"project-group-81/config"
+	"testing"
+	"time"
+)
+
+// Starts a test with an empty outbox kept in the file, posting to the endpoint
+func setup(t *testing.T, file string, url string) {
+	urls, retryMin, retryMax := config.WEBHOOK_URLS, config.WEBHOOK_RETRY_MIN, config.WEBHOOK_RETRY_MAX
+	t.Cleanup(func() {
+		config.WEBHOOK_URLS, config.WEBHOOK_RETRY_MIN, config.WEBHOOK_RETRY_MAX = urls, retryMin, retryMax
+	})
+	config.WEBHOOK_URLS = []string{url}
+	restart()
+	load(file)
+}
+
+// Forgets the outbox in memory, as a restarted process would
+func restart() {
+	outbox.Lock()
+	defer outbox.Unlock()
+	outbox.pending = nil
+	outbox.file = ""
+	outbox.loaded = false
+}
+
+func pending() []delivery {
+	outbox.Lock()
+	defer outbox.Unlock()
+	return append([]delivery{}, outbox.pending...)
+}
+
+// An endpoint answering every request with the status
+func endpoint(t *testing.T, status int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Id == "" {
+			t.Errorf("endpoint got an invalid event: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Makes one attempt at the delivery due first, as Run does
+func attempt(t *testing.T) {
+	t.Helper()
+	d, found := next()
+	if !found {
+		t.Fatal("outbox is empty")
+	}
+	retry, err := post(&http.Client{Timeout: time.Second}, d)
+	finish(d, retry, err)
+}
+
+func TestRetriesAndDrops(t *testing.T) {
+	for _, c := range []struct {
+		status int
+		kept   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNoContent, false},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+		{http.StatusGone, false},
+	} {
+		setup(t, "", endpoint(t, c.status).URL)
+		Notify(NodeInactive, "Lost contact with node", "peer", 1)
+		attempt(t)
+		left := pending()
+		if kept := len(left) == 1; kept != c.kept {
+			t.Errorf("status %d: kept %v, want %v", c.status, kept, c.kept)
+		} else if kept && left[0].Attempts != 1 {
+			t.Errorf("status %d: %d attempts counted, want 1", c.status, left[0].Attempts)
+		}
+	}
+}
+
+func TestRetriesUnreachableEndpoint(t *testing.T) {
+	server := endpoint(t, http.StatusOK)
+	server.Close()
+	setup(t, "", server.URL)
+	Notify(MasterFailover, "Took over as master")
+	before := time.Now()
+	attempt(t)
+	left := pending()
+	if len(left) != 1 {
+		t.Fatalf("%d deliveries left, want 1", len(left))
+	}
+	if left[0].NextAttempt.Before(before.Add(config.WEBHOOK_RETRY_MIN)) {
+		t.Errorf("retried at %v, less than %v after the attempt", left[0].NextAttempt, config.WEBHOOK_RETRY_MIN)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	setup(t, "", "http://localhost")
+	config.WEBHOOK_RETRY_MIN = time.Second
+	config.WEBHOOK_RETRY_MAX = time.Minute * 5
+	for attempts, want := range map[int]time.Duration{
+		1:    time.Second,
+		2:    time.Second * 2,
+		3:    time.Second * 4,
+		9:    time.Second * 256,
+		10:   time.Minute * 5,
+		1000: time.Minute * 5,
+	} {
+		if got := backoff(attempts); got != want {
+			t.Errorf("after %d attempts: got %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestOutboxSurvivesRestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "outbox-15657.json")
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	setup(t, file, server.URL)
+	Notify(HallCallWaiting, "Hall call is waiting", "floor", 2)
+	attempt(t)
+	sent := pending()
+
+	restart()
+	config.WEBHOOK_URLS = []string{server.URL, "http://localhost/new"}
+	load(file)
+	left := pending()
+	if len(left) != 1 || left[0].Event.Id != sent[0].Event.Id || left[0].Attempts != 1 || left[0].Event.Kind != HallCallWaiting {
+		t.Fatalf("got %+v after the restart, want %+v", left, sent)
+	}
+
+	// Events of endpoints taken out of the configuration are dropped
+	restart()
+	config.WEBHOOK_URLS = []string{"http://localhost/new"}
+	load(file)
+	if left := pending(); len(left) != 0 {
+		t.Errorf("kept %d deliveries to a removed endpoint", len(left))
+	}
+
+	restart()
+	config.WEBHOOK_URLS = []string{server.URL}
+	status = http.StatusOK
+	os.WriteFile(file, mustMarshal(t, sent), 0644)
+	load(file)
+	attempt(t)
+	var saved []delivery
+	blob, err := os.ReadFile(file)
+	if err == nil {
+		err = json.Unmarshal(blob, &saved)
+	}
+	if err != nil || len(saved) != 0 {
+		t.Errorf("outbox file holds %d deliveries after they were posted: %v", len(saved), err)
+	}
+}
+
+func mustMarshal(t *testing.T, value interface{}) []byte {
+	blob, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}